@@ -1,23 +1,25 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
-	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/pfc-developer/cosmos-exporter/pkg/exporter"
+	"github.com/pfc-developer/cosmos-exporter/pkg/extensions"
 )
 
 var (
-	config exporter.ServiceConfig
-	log    = zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout}).With().Timestamp().Logger()
+	config    exporter.ServiceConfig
+	available = extensions.All()
+	log       = zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout}).With().Timestamp().Logger()
 )
 
 var rootCmd = &cobra.Command{
@@ -29,24 +31,11 @@ var rootCmd = &cobra.Command{
 			return nil
 		}
 
-		viper.SetConfigFile(config.ConfigPath)
-		if err := viper.ReadInConfig(); err != nil {
-			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-				log.Info().Err(err).Msg("Error reading config file")
-				return err
-			}
+		if err := exporter.ApplyConfigFile(config.ConfigPath, cmd.Flags()); err != nil {
+			log.Info().Err(err).Msg("Error reading config file")
+			return err
 		}
 
-		// Credits to https://carolynvanslyck.com/blog/2020/08/sting-of-the-viper/
-		cmd.Flags().VisitAll(func(f *pflag.Flag) {
-			if !f.Changed && viper.IsSet(f.Name) {
-				val := viper.Get(f.Name)
-				if err := cmd.Flags().Set(f.Name, fmt.Sprintf("%v", val)); err != nil {
-					log.Fatal().Err(err).Msg("Could not set flag")
-				}
-			}
-		})
-
 		config.SetBechPrefixes(cmd)
 
 		return nil
@@ -54,7 +43,7 @@ var rootCmd = &cobra.Command{
 	Run: Execute,
 }
 
-func Execute(_ *cobra.Command, _ []string) {
+func Execute(cmd *cobra.Command, _ []string) {
 	logLevel, err := zerolog.ParseLevel(config.LogLevel)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Could not parse log level")
@@ -68,51 +57,73 @@ func Execute(_ *cobra.Command, _ []string) {
 
 	config.LogConfig(log.Info()).Msg("Started with following parameters")
 
-	sdkconfig := sdk.GetConfig()
-	sdkconfig.SetBech32PrefixForAccount(config.AccountPrefix, config.AccountPubkeyPrefix)
-	sdkconfig.SetBech32PrefixForValidator(config.ValidatorPrefix, config.ValidatorPubkeyPrefix)
-	sdkconfig.SetBech32PrefixForConsensusNode(config.ConsensusNodePrefix, config.ConsensusNodePubkeyPrefix)
-	sdkconfig.Seal()
+	// the server stops accepting requests on SIGTERM, then the deferred closes run
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
-	s := &exporter.Service{}
+	if config.ChainsPath != "" {
+		// every chain encodes its addresses with its own prefixes, the sdk config stays untouched
+		chains, err := exporter.NewChains(ctx, &config, log, available)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Could not set up chains")
+		}
+		defer chains.Close()
+
+		log.Info().Strs("chains", chains.Names()).Msg("Starting Multi-chain Mode")
+		http.HandleFunc("/metrics", chains.Handler)
+		http.HandleFunc("/metrics/", chains.Handler)
+		http.HandleFunc("/readyz", chains.ReadyzHandler)
+	} else {
+		enabled, err := exporter.ResolveExtensions(config.Extensions, available)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Could not enable extensions")
+		}
+		for _, ext := range enabled {
+			ext.Configure(&config)
+		}
 
-	s.Log = log
-	err = s.Connect(&config)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Could not connect to service")
-	}
-	defer func(service *exporter.Service) {
-		err := service.Close()
+		sdkconfig := sdk.GetConfig()
+		sdkconfig.SetBech32PrefixForAccount(config.AccountPrefix, config.AccountPubkeyPrefix)
+		sdkconfig.SetBech32PrefixForValidator(config.ValidatorPrefix, config.ValidatorPubkeyPrefix)
+		sdkconfig.SetBech32PrefixForConsensusNode(config.ConsensusNodePrefix, config.ConsensusNodePubkeyPrefix)
+		sdkconfig.Seal()
+
+		s := &exporter.Service{}
+
+		s.Log = log
+		err = s.Connect(&config)
 		if err != nil {
-			s.Log.Fatal().Err(err).Msg("Could not close service client")
+			log.Fatal().Err(err).Msg("Could not connect to service")
+		}
+		defer func(service *exporter.Service) {
+			err := service.Close()
+			if err != nil {
+				s.Log.Fatal().Err(err).Msg("Could not close service client")
+			}
+		}(s)
+
+		s.SetChainID(&config)
+		s.SetDenom(&config)
+		s.SetPrices(&config)
+		s.Configure(&config)
+		s.Extensions = enabled
+
+		for path, handler := range s.Routes(ctx) {
+			http.HandleFunc("/metrics"+path, handler)
+		}
+		http.HandleFunc("/readyz", s.ReadyzHandler)
+
+		if config.ConfigPath != "" {
+			reload := func() {
+				if err := s.ReloadConfig(cmd.Flags()); err != nil {
+					log.Error().Err(err).Msg("Could not reload config, keeping the previous one")
+				}
+			}
+			exporter.WatchConfig(ctx, log, config.ConfigPath, reload)
 		}
-	}(s)
-
-	s.SetChainID(&config)
-	s.SetDenom(&config)
-
-	s.Params = config.Params
-	s.Wallets = config.Wallets
-	s.Validators = config.Validators
-	s.Proposals = config.Proposals
-	s.Oracle = config.Oracle
-	s.Params = config.Params
-	s.Upgrades = config.Upgrades
-	s.Config = &config
-
-	if config.SingleReq {
-		log.Info().Msg("Starting Single Mode")
-		http.HandleFunc("/metrics", s.SingleHandler)
 	}
-	http.HandleFunc("/metrics/wallet", s.WalletHandler)
-	http.HandleFunc("/metrics/validator", s.ValidatorHandler)
-	http.HandleFunc("/metrics/validators", s.ValidatorsHandler)
-	http.HandleFunc("/metrics/params", s.ParamsHandler)
-	http.HandleFunc("/metrics/general", s.GeneralHandler)
 
-	http.HandleFunc("/metrics/delegator", s.DelegatorHandler)
-	http.HandleFunc("/metrics/proposals", s.ProposalsHandler)
-	http.HandleFunc("/metrics/upgrade", s.UpgradeHandler)
+	http.HandleFunc("/healthz", exporter.HealthzHandler)
 
 	/*
 		if Prefix == "sei" {
@@ -127,17 +138,20 @@ func Execute(_ *cobra.Command, _ []string) {
 			eventCollector.StreamHandler(w, r)
 		})
 	*/
-	log.Info().Str("address", config.ListenAddress).Msg("Listening")
-	err = http.ListenAndServe(config.ListenAddress, nil) // #nosec
+	err = exporter.ListenAndServe(ctx, &config, log, http.DefaultServeMux)
 	if err != nil {
 		log.Error().Err(err).Msg("Could not start application")
 		return
 	}
+	log.Info().Msg("Stopped")
 }
 
 func main() {
 	config.SetCommonParameters(rootCmd)
 	config.SetIsInitia(false)
+	for _, ext := range available {
+		ext.RegisterFlags(rootCmd.PersistentFlags())
+	}
 
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal().Err(err).Msg("Could not start application")
@@ -7,10 +7,10 @@ echo -e "\e[1m\e[32m1. Installing cosmos-exporter... \e[0m" && sleep 1
 source ~/.bashrc
 
 # install cosmos-exporter
-sudo /usr/local/go/bin/go build -o build/sei-cosmos-exporter -buildvcs=false ./
-sudo /usr/bin/go build -o build/sei-cosmos-exporter -buildvcs=false ./
-sudo go build -o build/sei-cosmos-exporter -buildvcs=false ./
-sudo mv ./build/sei-cosmos-exporter /usr/bin
+sudo /usr/local/go/bin/go build -o build/cosmos-exporter -buildvcs=false ./cmd/cosmos-exporter
+sudo /usr/bin/go build -o build/cosmos-exporter -buildvcs=false ./cmd/cosmos-exporter
+sudo go build -o build/cosmos-exporter -buildvcs=false ./cmd/cosmos-exporter
+sudo mv ./build/cosmos-exporter /usr/bin
 
 sudo useradd -rs /bin/false cosmos_exporter
 
@@ -25,7 +25,7 @@ Group=cosmos_exporter
 TimeoutStartSec=0
 CPUWeight=95
 IOWeight=95
-ExecStart=/usr/bin/sei-cosmos-exporter --denom $BOND_DENOM --denom-coefficient 1000000 --bech-prefix $BENCH_PREFIX
+ExecStart=/usr/bin/cosmos-exporter --extensions=sei --denom $BOND_DENOM --denom-coefficient 1000000 --bech-prefix $BENCH_PREFIX
 Restart=always
 RestartSec=2
 LimitNOFILE=800000
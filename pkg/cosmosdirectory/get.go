@@ -1,21 +1,108 @@
 package cosmosdirectory
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
-const CosmosDirectoryURL = "https://chains.cosmos.directory"
+const (
+	CosmosDirectoryURL = "https://chains.cosmos.directory"
+	// DefaultTTL is how long the chain list is used before being downloaded again.
+	DefaultTTL = 5 * time.Minute
+	// DefaultErrorTTL is how long a failed download is remembered before trying again.
+	DefaultErrorTTL = 30 * time.Second
+)
+
+var ErrChainNotFound = errors.New("chain not found")
+
+// DefaultClient is used by GetChain and GetChainByChainID.
+var DefaultClient = NewClient(CosmosDirectoryURL)
+
+// Client looks chains up in the chain list of cosmos.directory. The list is kept in memory
+// for TTL. Once expired, it keeps being served while a single request refreshes it in the
+// background, so only the first lookup waits for the download. A failed download is not tried
+// again for ErrorTTL, the lookups meanwhile get its error or the list they had.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	TTL        time.Duration
+	ErrorTTL   time.Duration
+
+	mu         sync.Mutex
+	index      *index
+	fetched    time.Time
+	refreshing bool
+	err        error
+	failed     time.Time
+}
+
+// NewClient returns a client of the cosmos.directory instance at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{Timeout: 2 * time.Second},
+		TTL:        DefaultTTL,
+		ErrorTTL:   DefaultErrorTTL,
+	}
+}
+
+// index holds a chain list along with its chains by chain ID, name and denom.
+type index struct {
+	directory *CosmosDirectory
+	byChainID map[string]*Chain
+	byName    map[string]*Chain
+	byDenom   map[string]*Chain
+}
+
+func newIndex(directory *CosmosDirectory) *index {
+	idx := &index{
+		directory: directory,
+		byChainID: make(map[string]*Chain, len(directory.Chains)),
+		byName:    make(map[string]*Chain, 2*len(directory.Chains)),
+		byDenom:   make(map[string]*Chain, len(directory.Chains)),
+	}
+	for i := range directory.Chains {
+		chain := &directory.Chains[i]
+		add(idx.byChainID, chain.ChainID, chain)
+		add(idx.byName, chain.Name, chain)
+		add(idx.byName, chain.ChainName, chain)
+		add(idx.byDenom, chain.Denom, chain)
+	}
+	return idx
+}
+
+// add indexes chain under key, unless another chain already is: the first one wins, as with
+// the former linear lookups.
+func add(chains map[string]*Chain, key string, chain *Chain) {
+	if _, ok := chains[key]; key != "" && !ok {
+		chains[key] = chain
+	}
+}
+
+func (c *Client) query(ctx context.Context) (*CosmosDirectory, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BaseURL, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
 
-func query() (*CosmosDirectory, error) {
-	httpClient := &http.Client{Timeout: 2 * time.Second}
-	r, err := httpClient.Get(CosmosDirectoryURL)
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	r, err := httpClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cosmos.directory answered %s", r.Status)
+	}
 
 	resp := &CosmosDirectory{}
 
@@ -27,30 +114,93 @@ func query() (*CosmosDirectory, error) {
 	return resp, nil
 }
 
-func GetChain(name string) (*Chain, error) {
-	resp, err := query()
-	if err != nil {
-		return nil, err
-	}
+func (c *Client) load(ctx context.Context) (*index, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	for _, chain := range resp.Chains {
-		if chain.Name == name || chain.ChainName == name {
-			return &chain, nil
+	backingOff := c.err != nil && time.Since(c.failed) < c.ErrorTTL
+
+	if c.index == nil {
+		if backingOff {
+			return nil, c.err
+		}
+		// nothing to serve yet, callers wait for the download
+		directory, err := c.query(ctx)
+		if err != nil {
+			c.err, c.failed = err, time.Now()
+			return nil, err
 		}
+		c.index = newIndex(directory)
+		c.fetched = time.Now()
+		c.err = nil
+		return c.index, nil
+	}
+
+	if time.Since(c.fetched) >= c.TTL && !c.refreshing && !backingOff {
+		c.refreshing = true
+		go c.refresh()
 	}
-	return nil, errors.New("chain not found")
+	return c.index, nil
 }
 
-func GetChainByChainID(chainID string) (*Chain, error) {
-	resp, err := query()
+// refresh downloads the chain list again, keeping the current one on failure.
+func (c *Client) refresh() {
+	directory, err := c.query(context.Background())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = false
+	if err != nil {
+		c.err, c.failed = err, time.Now()
+		return
+	}
+	c.index = newIndex(directory)
+	c.fetched = time.Now()
+	c.err = nil
+}
+
+// Directory returns the whole chain list.
+func (c *Client) Directory(ctx context.Context) (*CosmosDirectory, error) {
+	idx, err := c.load(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return idx.directory, nil
+}
 
-	for _, chain := range resp.Chains {
-		if chain.ChainID == chainID {
-			return &chain, nil
-		}
+func (c *Client) lookup(ctx context.Context, by func(*index) map[string]*Chain, key string) (*Chain, error) {
+	idx, err := c.load(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("chain not found")
+	chain, ok := by(idx)[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrChainNotFound, key)
+	}
+	// a copy, the cached list is shared
+	found := *chain
+	return &found, nil
+}
+
+// ChainByChainID returns the chain with the given chain ID, e.g. cosmoshub-4.
+func (c *Client) ChainByChainID(ctx context.Context, chainID string) (*Chain, error) {
+	return c.lookup(ctx, func(idx *index) map[string]*Chain { return idx.byChainID }, chainID)
+}
+
+// ChainByName returns the chain with the given cosmos.directory or chain registry name.
+func (c *Client) ChainByName(ctx context.Context, name string) (*Chain, error) {
+	return c.lookup(ctx, func(idx *index) map[string]*Chain { return idx.byName }, name)
+}
+
+// ChainByDenom returns the chain whose native base denom is denom, e.g. uatom.
+func (c *Client) ChainByDenom(ctx context.Context, denom string) (*Chain, error) {
+	return c.lookup(ctx, func(idx *index) map[string]*Chain { return idx.byDenom }, denom)
+}
+
+func GetChain(name string) (*Chain, error) {
+	return DefaultClient.ChainByName(context.Background(), name)
+}
+
+func GetChainByChainID(chainID string) (*Chain, error) {
+	return DefaultClient.ChainByChainID(context.Background(), chainID)
 }
@@ -1,53 +1,141 @@
 package cosmosdirectory_test
 
 import (
-	"fmt"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/pfc-developer/cosmos-exporter/pkg/cosmosdirectory"
 )
 
-func TestGetChain(t *testing.T) {
+const directory = `{
+  "repository": {"url": "https://github.com/cosmos/chain-registry", "branch": "master"},
+  "chains": [
+    {
+      "name": "juno", "chain_name": "juno", "chain_id": "juno-1", "denom": "ujuno", "display": "juno",
+      "prices": {"coingecko": {"juno": {"usd": 0.25}}},
+      "assets": [
+        {"denom": "ujuno", "base": {"denom": "ujuno"}, "display": {"denom": "juno", "exponent": 6},
+         "prices": {"coingecko": {"juno": {"usd": 0.25}}}}
+      ]
+    },
+    {
+      "name": "cosmoshub", "chain_name": "cosmoshub", "chain_id": "cosmoshub-4", "denom": "uatom", "display": "atom",
+      "prices": {"coingecko": {"atom": {"usd": 7.5}}}
+    },
+    {
+      "name": "kichain", "chain_name": "ki", "chain_id": "kichain-2", "denom": "uxki", "display": "xki",
+      "prices": {"coingecko": {}}
+    }
+  ]
+}`
+
+// startDirectory serves body as the chain list and counts the requests.
+func startDirectory(t *testing.T, body *atomic.Value) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	requests := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		content, _ := body.Load().(string)
+		if content == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestClientLookups(t *testing.T) {
+	var body atomic.Value
+	body.Store(directory)
+	server, requests := startDirectory(t, &body)
+	client := cosmosdirectory.NewClient(server.URL)
+	ctx := context.Background()
+
 	tests := []struct {
-		Chain string
+		Name   string
+		Lookup func() (*cosmosdirectory.Chain, error)
+		Price  float64
 	}{
-		{Chain: "juno"},
-		{Chain: "stargaze"},
-		{Chain: "cheqd"},
+		{Name: "by chain ID", Lookup: func() (*cosmosdirectory.Chain, error) { return client.ChainByChainID(ctx, "juno-1") }, Price: 0.25},
+		{Name: "by name", Lookup: func() (*cosmosdirectory.Chain, error) { return client.ChainByName(ctx, "cosmoshub") }, Price: 7.5},
+		{Name: "by chain registry name", Lookup: func() (*cosmosdirectory.Chain, error) { return client.ChainByName(ctx, "ki") }, Price: 0},
+		{Name: "by denom", Lookup: func() (*cosmosdirectory.Chain, error) { return client.ChainByDenom(ctx, "uatom") }, Price: 7.5},
 	}
 
 	for _, test := range tests {
-		t.Run(test.Chain, func(t *testing.T) {
-			chain, err := cosmosdirectory.GetChain(test.Chain)
+		t.Run(test.Name, func(t *testing.T) {
+			chain, err := test.Lookup()
 			require.NoError(t, err)
 			require.NotNil(t, chain)
-			require.NotZero(t, chain.GetPriceUSD())
+			require.Equal(t, test.Price, chain.GetPriceUSD())
 		})
 	}
+
+	chain, err := client.ChainByChainID(ctx, "juno-1")
+	require.NoError(t, err)
+	require.Len(t, chain.Assets, 1)
+	require.Equal(t, 0.25, chain.Assets[0].GetPriceUSD())
+
+	_, err = client.ChainByChainID(ctx, "unknown-1")
+	require.ErrorIs(t, err, cosmosdirectory.ErrChainNotFound)
+
+	// every lookup used the cached list
+	require.Equal(t, int32(1), requests.Load())
 }
 
-func TestGetChainByChainID(t *testing.T) {
-	tests := []struct {
-		ChainID string
-	}{
-		{ChainID: "juno-1"},
-		{ChainID: "stargaze-1"},
-		{ChainID: "cheqd-mainnet-1"},
-		{ChainID: "kichain-2"},
-		{ChainID: "Oraichain"},
-		{ChainID: "chihuahua-1"},
-	}
+func TestClientStaleWhileRevalidate(t *testing.T) {
+	var body atomic.Value
+	server, requests := startDirectory(t, &body)
+	client := cosmosdirectory.NewClient(server.URL)
+	client.TTL = time.Millisecond
 
-	for _, test := range tests {
-		t.Run(test.ChainID, func(t *testing.T) {
-			chain, err := cosmosdirectory.GetChainByChainID(test.ChainID)
-			require.NoError(t, err)
-			require.NotNil(t, chain)
-			require.NotZero(t, chain.GetPriceUSD())
+	client.ErrorTTL = time.Hour
+	ctx := context.Background()
 
-			fmt.Printf("price: %v\n", chain.GetPriceUSD())
-		})
+	// nothing cached yet, the error is returned
+	_, err := client.ChainByChainID(ctx, "juno-1")
+	require.Error(t, err)
+
+	// and remembered, the lookups do not wait for downloads bound to fail
+	body.Store(directory)
+	for range 3 {
+		_, err = client.ChainByChainID(ctx, "juno-1")
+		require.Error(t, err)
 	}
+	require.Equal(t, int32(1), requests.Load())
+
+	client.ErrorTTL = time.Millisecond
+	time.Sleep(2 * client.ErrorTTL)
+	chain, err := client.ChainByChainID(ctx, "juno-1")
+	require.NoError(t, err)
+	require.Equal(t, "juno", chain.Name)
+
+	// expired: the cached list is served while the new one is downloaded
+	body.Store(`{"chains": [{"name": "juno2", "chain_id": "juno-1"}]}`)
+	time.Sleep(2 * client.TTL)
+	chain, err = client.ChainByChainID(ctx, "juno-1")
+	require.NoError(t, err)
+	require.Equal(t, "juno", chain.Name)
+
+	require.Eventually(t, func() bool {
+		chain, err := client.ChainByChainID(ctx, "juno-1")
+		return err == nil && chain.Name == "juno2"
+	}, time.Second, 5*time.Millisecond)
+
+	// a failed refresh keeps the cached list
+	body.Store("")
+	before := requests.Load()
+	require.Eventually(t, func() bool {
+		chain, err := client.ChainByChainID(ctx, "juno-1")
+		return err == nil && chain.Name == "juno2" && requests.Load() > before+1
+	}, time.Second, 5*time.Millisecond)
 }
@@ -49,6 +49,19 @@ func (chain Chain) GetPriceUSD() float64 {
 	return price
 }
 
+// GetPriceUSD returns the price of one display unit of the asset, 0 if unknown.
+func (asset Assets) GetPriceUSD() float64 {
+	prices, exist := asset.Prices.Coingecko[asset.Display.Denom].(map[string]interface{})
+	if !exist {
+		return float64(0)
+	}
+	price, exist := prices["usd"].(float64)
+	if !exist {
+		return float64(0)
+	}
+	return price
+}
+
 type CoingeckoPrices map[string]interface{} // map[string]interface{}
 
 type Prices struct {
@@ -0,0 +1,58 @@
+package exporter
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// The sdk address types encode and decode with the process wide sdk config, which only
+// holds the prefixes of a single chain. The helpers below use the prefixes of the
+// ServiceConfig instead so one process can serve several chains.
+
+func (config *ServiceConfig) ValAddressFromBech32(address string) (sdk.ValAddress, error) {
+	bz, err := sdk.GetFromBech32(address, config.ValidatorPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return sdk.ValAddress(bz), sdk.VerifyAddressFormat(bz)
+}
+
+func (config *ServiceConfig) AccAddressFromBech32(address string) (sdk.AccAddress, error) {
+	bz, err := sdk.GetFromBech32(address, config.AccountPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return sdk.AccAddress(bz), sdk.VerifyAddressFormat(bz)
+}
+
+func (config *ServiceConfig) ConsAddressFromBech32(address string) (sdk.ConsAddress, error) {
+	bz, err := sdk.GetFromBech32(address, config.ConsensusNodePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return sdk.ConsAddress(bz), sdk.VerifyAddressFormat(bz)
+}
+
+func (config *ServiceConfig) ValAddressString(address sdk.ValAddress) string {
+	return bech32String(config.ValidatorPrefix, address)
+}
+
+func (config *ServiceConfig) AccAddressString(address sdk.AccAddress) string {
+	return bech32String(config.AccountPrefix, address)
+}
+
+func (config *ServiceConfig) ConsAddressString(address sdk.ConsAddress) string {
+	return bech32String(config.ConsensusNodePrefix, address)
+}
+
+// bech32String mirrors the String methods of the sdk address types: empty addresses
+// and unencodable ones are rendered as an empty string.
+func bech32String(prefix string, address []byte) string {
+	if len(address) == 0 {
+		return ""
+	}
+	encoded, err := sdk.Bech32ifyAddressBytes(prefix, address)
+	if err != nil {
+		return ""
+	}
+	return encoded
+}
@@ -0,0 +1,429 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"time"
+
+	tmrpc "github.com/cometbft/cometbft/rpc/client/http"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// blockSource is the part of the CometBFT RPC client the block watcher uses.
+type blockSource interface {
+	Status(ctx context.Context) (*coretypes.ResultStatus, error)
+	Block(ctx context.Context, height *int64) (*coretypes.ResultBlock, error)
+	Validators(ctx context.Context, height *int64, page, perPage *int) (*coretypes.ResultValidators, error)
+}
+
+// BlockWatcher follows the blocks of --tendermint-rpc and records, for every --validators
+// entry, whether it signed them and how many it proposed. Unlike the missed blocks counter of
+// the slashing module, its counters never reset, and it tells how many blocks in a row a
+// validator missed.
+type BlockWatcher struct {
+	s      *Service
+	log    zerolog.Logger
+	source blockSource
+	window int
+
+	// lastHeight is the last processed block, lastHash the hash of the validator set of that block
+	lastHeight int64
+	lastHash   []byte
+	// members is the voting power of the validator set which signed the commit being
+	// processed, by consensus address
+	members      map[string]int64
+	membersPower int64
+	membersHash  []byte
+
+	// tracked are keyed by operator address
+	tracked  map[string]*signatures
+	resolved time.Time
+
+	signedCounter *prometheus.CounterVec
+	missedCounter *prometheus.CounterVec
+	streakGauge   *prometheus.GaugeVec
+	uptimeGauge   *prometheus.GaugeVec
+	heightGauge   prometheus.Gauge
+
+	proposedCounter         *prometheus.CounterVec
+	expectedProposedCounter *prometheus.CounterVec
+	proposalShareGauge      *prometheus.GaugeVec
+}
+
+// signatures holds what the watcher knows of a validator.
+type signatures struct {
+	moniker string
+	// consAddress is the hex consensus address, as found in the commits
+	consAddress string
+	// configured is the --validatorcons entry consAddress comes from, empty if it was found
+	// in the validator set
+	configured string
+	// recent are the last window blocks the validator had to sign, oldest first
+	recent []bool
+	streak int
+}
+
+func (t *signatures) uptime() float64 {
+	signed := 0
+	for _, ok := range t.recent {
+		if ok {
+			signed++
+		}
+	}
+	return float64(signed) / float64(len(t.recent))
+}
+
+// NewBlockWatcher connects to --tendermint-rpc. Its metrics are served on every endpoint,
+// next to the exporter metrics.
+func NewBlockWatcher(s *Service) (*BlockWatcher, error) {
+	client, err := tmrpc.New(s.Config.TendermintRPC, "/websocket")
+	if err != nil {
+		return nil, err
+	}
+	return newBlockWatcher(s, client), nil
+}
+
+func newBlockWatcher(s *Service, source blockSource) *BlockWatcher {
+	w := &BlockWatcher{
+		s:       s,
+		log:     s.Log.With().Str("component", "block-watcher").Logger(),
+		source:  source,
+		window:  max(s.Config.BlockWatcherWindow, 1),
+		tracked: make(map[string]*signatures),
+
+		signedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_validator_blocks_signed_total",
+				Help:        "Blocks signed by the Cosmos-based blockchain validator since the exporter started",
+				ConstLabels: s.Config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		missedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_validator_blocks_missed_total",
+				Help:        "Blocks missed by the Cosmos-based blockchain validator since the exporter started",
+				ConstLabels: s.Config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		streakGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_missed_blocks_streak",
+				Help:        "Consecutive blocks missed by the Cosmos-based blockchain validator up to the latest block",
+				ConstLabels: s.Config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		uptimeGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_uptime_ratio",
+				Help:        "Share of the recent blocks signed by the Cosmos-based blockchain validator, see --block-watcher-window",
+				ConstLabels: s.Config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		proposedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_validator_blocks_proposed_total",
+				Help:        "Blocks proposed by the Cosmos-based blockchain validator since the exporter started",
+				ConstLabels: s.Config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		expectedProposedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_validator_blocks_proposed_expected_total",
+				Help:        "Blocks the Cosmos-based blockchain validator was expected to propose since the exporter started, from its share of the voting power",
+				ConstLabels: s.Config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		proposalShareGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_expected_proposal_share",
+				Help:        "Share of the blocks the Cosmos-based blockchain validator is expected to propose, its share of the voting power",
+				ConstLabels: s.Config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		heightGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_exporter_block_watcher_height",
+				Help:        "Last block processed by the block watcher",
+				ConstLabels: s.Config.ConstLabels,
+			},
+		),
+	}
+
+	s.Metrics.registry.MustRegister(w.signedCounter)
+	s.Metrics.registry.MustRegister(w.missedCounter)
+	s.Metrics.registry.MustRegister(w.streakGauge)
+	s.Metrics.registry.MustRegister(w.uptimeGauge)
+	s.Metrics.registry.MustRegister(w.heightGauge)
+	s.Metrics.registry.MustRegister(w.proposedCounter)
+	s.Metrics.registry.MustRegister(w.expectedProposedCounter)
+	s.Metrics.registry.MustRegister(w.proposalShareGauge)
+
+	return w
+}
+
+// Start follows the chain until ctx is cancelled.
+func (w *BlockWatcher) Start(ctx context.Context) {
+	w.log.Info().
+		Str("node", w.s.Config.TendermintRPC).
+		Int("window", w.window).
+		Dur("interval", w.s.Config.BlockWatcherInterval).
+		Msg("Starting block watcher")
+
+	go func() {
+		ticker := time.NewTicker(w.s.Config.BlockWatcherInterval)
+		defer ticker.Stop()
+
+		for {
+			w.poll(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// poll processes the blocks made since the last call. On the first call, and after falling
+// too far behind, it starts window blocks before the latest one.
+func (w *BlockWatcher) poll(ctx context.Context) {
+	w.resolve(ctx)
+	if len(w.tracked) == 0 {
+		return
+	}
+
+	status, err := withQueryTimeout(ctx, w.s.Config.QueryTimeout, w.source.Status)
+	if err != nil {
+		w.log.Error().Err(err).Msg("Could not get node status")
+		return
+	}
+
+	latest := status.SyncInfo.LatestBlockHeight
+	// the first block has no last commit
+	from := max(w.lastHeight+1, latest-int64(w.window)+1, 2)
+	for height := from; height <= latest; height++ {
+		if err := w.processBlock(ctx, height); err != nil {
+			w.log.Error().
+				Int64("height", height).
+				Err(err).
+				Msg("Could not process block, retrying on the next poll")
+			return
+		}
+	}
+}
+
+// processBlock records who signed the last commit of the block, the one of the block before.
+func (w *BlockWatcher) processBlock(ctx context.Context, height int64) error {
+	block, err := withQueryTimeout(ctx, w.s.Config.QueryTimeout, func(ctx context.Context) (*coretypes.ResultBlock, error) {
+		return w.source.Block(ctx, &height)
+	})
+	if err != nil {
+		return err
+	}
+
+	// the hash of the set of the previous block is known when that block was processed, the
+	// set is only fetched again when it changes
+	var previousHash []byte
+	if w.lastHeight == height-1 {
+		previousHash = w.lastHash
+	}
+	if w.members == nil || previousHash == nil || !bytes.Equal(previousHash, w.membersHash) {
+		if err := w.loadMembers(ctx, height-1); err != nil {
+			return err
+		}
+		w.membersHash = previousHash
+	}
+
+	signed := make(map[string]bool)
+	if commit := block.Block.LastCommit; commit != nil {
+		for _, signature := range commit.Signatures {
+			// a nil vote still proves the validator is online
+			if signature.BlockIDFlag != cmttypes.BlockIDFlagAbsent {
+				signed[signature.ValidatorAddress.String()] = true
+			}
+		}
+	}
+
+	proposer := block.Block.ProposerAddress.String()
+	for operator, tracker := range w.tracked {
+		labels := prometheus.Labels{"address": operator, "moniker": tracker.moniker}
+		if tracker.consAddress == proposer {
+			w.proposedCounter.With(labels).Inc()
+		}
+
+		power, ok := w.members[tracker.consAddress]
+		// not in the active set, nothing to sign or propose
+		if !ok {
+			w.proposalShareGauge.With(labels).Set(0)
+			continue
+		}
+		w.record(operator, tracker, signed[tracker.consAddress])
+
+		// the proposers rotate in proportion to the voting power, the set of the previous
+		// block is close enough to the one of this block
+		if w.membersPower > 0 {
+			share := float64(power) / float64(w.membersPower)
+			w.proposalShareGauge.With(labels).Set(share)
+			w.expectedProposedCounter.With(labels).Add(share)
+		}
+		w.proposedCounter.With(labels).Add(0)
+	}
+
+	w.lastHeight = height
+	w.lastHash = block.Block.ValidatorsHash
+	w.heightGauge.Set(float64(height))
+	return nil
+}
+
+// loadMembers fetches the validator set of the given height.
+func (w *BlockWatcher) loadMembers(ctx context.Context, height int64) error {
+	members := make(map[string]int64)
+	var power int64
+	perPage := 100
+	for page := 1; ; page++ {
+		result, err := withQueryTimeout(ctx, w.s.Config.QueryTimeout, func(ctx context.Context) (*coretypes.ResultValidators, error) {
+			return w.source.Validators(ctx, &height, &page, &perPage)
+		})
+		if err != nil {
+			return err
+		}
+		for _, validator := range result.Validators {
+			members[validator.Address.String()] = validator.VotingPower
+			power += validator.VotingPower
+		}
+		if len(result.Validators) == 0 || len(members) >= result.Total {
+			break
+		}
+	}
+	w.members = members
+	w.membersPower = power
+	return nil
+}
+
+func (w *BlockWatcher) record(operator string, tracker *signatures, signed bool) {
+	labels := prometheus.Labels{"address": operator, "moniker": tracker.moniker}
+
+	tracker.recent = append(tracker.recent, signed)
+	if len(tracker.recent) > w.window {
+		tracker.recent = tracker.recent[len(tracker.recent)-w.window:]
+	}
+	if signed {
+		tracker.streak = 0
+		w.signedCounter.With(labels).Inc()
+	} else {
+		tracker.streak++
+		w.missedCounter.With(labels).Inc()
+	}
+	// created along with the other one, so both show up from the first block
+	w.signedCounter.With(labels).Add(0)
+	w.missedCounter.With(labels).Add(0)
+
+	w.streakGauge.With(labels).Set(float64(tracker.streak))
+	w.uptimeGauge.With(labels).Set(tracker.uptime())
+}
+
+// resolve follows the --validators and --validatorcons entries, as reloaded, to the consensus
+// addresses found in the commits. Operators are looked up in the validator set at most once a
+// minute, in case they are not yet created. The set also gives the moniker of the operators
+// paired with a --validatorcons entry, which keep "n/a" if it does not have them.
+func (w *BlockWatcher) resolve(ctx context.Context) {
+	w.s.configMu.RLock()
+	validators := slices.Clone(w.s.Validators)
+	validatorCons := slices.Clone(w.s.ValidatorCons)
+	w.s.configMu.RUnlock()
+
+	// paired by position, as in single mode
+	configured := make(map[string]string, len(validators))
+	for index, operator := range validators {
+		if index < len(validatorCons) {
+			configured[operator] = validatorCons[index]
+		}
+	}
+
+	for operator, tracker := range w.tracked {
+		if !slices.Contains(validators, operator) || tracker.configured != configured[operator] {
+			w.forget(operator, tracker)
+		}
+	}
+
+	var missing []string
+	for _, operator := range validators {
+		if _, ok := w.tracked[operator]; !ok {
+			missing = append(missing, operator)
+		}
+	}
+
+	if len(missing) == 0 || time.Since(w.resolved) < time.Minute {
+		return
+	}
+	w.resolved = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	validatorSet := w.s.LoadValidatorSet(ctx, &w.log)
+	for _, operator := range missing {
+		validator, _ := validatorSet.Validator(operator)
+		tracker := &signatures{moniker: validator.Description.Moniker, configured: configured[operator]}
+
+		if tracker.configured != "" {
+			consAddress, err := w.s.Config.ConsAddressFromBech32(tracker.configured)
+			if err != nil {
+				w.log.Error().
+					Str("consaddress", tracker.configured).
+					Err(err).
+					Msg("Could not decode validatorcons entry")
+				continue
+			}
+			tracker.consAddress = cmttypes.Address(consAddress).String()
+			if tracker.moniker == "" {
+				tracker.moniker = "n/a"
+			}
+		} else {
+			consAddress := validatorSet.ConsAddress(operator)
+			if consAddress == nil {
+				w.log.Warn().
+					Str("address", operator).
+					Msg("Could not find the consensus address of the validator")
+				continue
+			}
+			tracker.consAddress = cmttypes.Address(consAddress).String()
+		}
+
+		w.tracked[operator] = tracker
+	}
+}
+
+// forget drops a validator no longer monitored, along with its series.
+func (w *BlockWatcher) forget(operator string, tracker *signatures) {
+	labels := prometheus.Labels{"address": operator, "moniker": tracker.moniker}
+	w.signedCounter.Delete(labels)
+	w.missedCounter.Delete(labels)
+	w.streakGauge.Delete(labels)
+	w.uptimeGauge.Delete(labels)
+	w.proposedCounter.Delete(labels)
+	w.expectedProposedCounter.Delete(labels)
+	w.proposalShareGauge.Delete(labels)
+	delete(w.tracked, operator)
+}
+
+// withQueryTimeout bounds a single RPC call with --query-timeout.
+func withQueryTimeout[T any](ctx context.Context, timeout time.Duration, call func(context.Context) (T, error)) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return call(ctx)
+}
@@ -0,0 +1,166 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// fakeBlocks serves blocks whose last commit is signed by signers[height] and voted nil by
+// nilVotes[height], all made by the same validator set. The blocks are proposed in turn by
+// proposers.
+type fakeBlocks struct {
+	latest     int64
+	set        []cmttypes.Address
+	power      []int64
+	proposers  []cmttypes.Address
+	signers    map[int64][]cmttypes.Address
+	nilVotes   map[int64][]cmttypes.Address
+	setQueries int
+}
+
+func (f *fakeBlocks) Status(_ context.Context) (*coretypes.ResultStatus, error) {
+	return &coretypes.ResultStatus{SyncInfo: coretypes.SyncInfo{LatestBlockHeight: f.latest}}, nil
+}
+
+func (f *fakeBlocks) Block(_ context.Context, height *int64) (*coretypes.ResultBlock, error) {
+	commit := &cmttypes.Commit{Height: *height - 1}
+	for _, address := range f.set {
+		signature := cmttypes.CommitSig{BlockIDFlag: cmttypes.BlockIDFlagAbsent}
+		for _, signer := range f.signers[*height] {
+			if signer.String() == address.String() {
+				signature = cmttypes.CommitSig{BlockIDFlag: cmttypes.BlockIDFlagCommit, ValidatorAddress: address}
+			}
+		}
+		for _, signer := range f.nilVotes[*height] {
+			if signer.String() == address.String() {
+				signature = cmttypes.CommitSig{BlockIDFlag: cmttypes.BlockIDFlagNil, ValidatorAddress: address}
+			}
+		}
+		commit.Signatures = append(commit.Signatures, signature)
+	}
+	return &coretypes.ResultBlock{Block: &cmttypes.Block{
+		Header: cmttypes.Header{
+			Height:          *height,
+			ValidatorsHash:  []byte("set"),
+			ProposerAddress: f.proposers[int(*height)%len(f.proposers)],
+		},
+		LastCommit: commit,
+	}}, nil
+}
+
+func (f *fakeBlocks) Validators(_ context.Context, _ *int64, _, _ *int) (*coretypes.ResultValidators, error) {
+	f.setQueries++
+	result := &coretypes.ResultValidators{Total: len(f.set)}
+	for index, address := range f.set {
+		result.Validators = append(result.Validators, &cmttypes.Validator{Address: address, VotingPower: f.power[index]})
+	}
+	return result, nil
+}
+
+func TestBlockWatcher(t *testing.T) {
+	first := cmttypes.Address([]byte("first-validator-cons"))
+	second := cmttypes.Address([]byte("second-validatorcons"))
+	inactive := cmttypes.Address([]byte("inactive-validator-c"))
+	blocks := &fakeBlocks{
+		latest: 6,
+		set:    []cmttypes.Address{first, second},
+		power:  []int64{25, 75},
+		// the first validator proposes one block out of four
+		proposers: []cmttypes.Address{first, second, second, second},
+		signers: map[int64][]cmttypes.Address{
+			// too old for the window of 4 blocks
+			2: {second},
+			3: {first, second},
+			4: {first, second},
+			5: {second},
+			6: {second},
+		},
+		// online, but voted for another block
+		nilVotes: map[int64][]cmttypes.Address{6: {first}},
+	}
+
+	// the monikers come from the validator set, the inactive validator is not in it
+	firstValidator, _ := fakeValidator(t, "cosmosvaloper1first", 100, stakingtypes.Bonded, false)
+	secondValidator, _ := fakeValidator(t, "cosmosvaloper1second", 100, stakingtypes.Bonded, false)
+	firstValidator.Description.Moniker = "first"
+	secondValidator.Description.Moniker = "second"
+	s := startFakeChain(t, func(server *grpc.Server) {
+		stakingtypes.RegisterQueryServer(server, &fakeStaking{validators: []stakingtypes.Validator{firstValidator, secondValidator}, maxValidators: 2})
+	})
+	config := s.Config
+	config.BlockWatcherWindow = 4
+	s.Metrics = NewExporterMetrics(config)
+	s.Validators = []string{"cosmosvaloper1first", "cosmosvaloper1second", "cosmosvaloper1inactive"}
+	s.ValidatorCons = []string{
+		config.ConsAddressString(sdk.ConsAddress(first)),
+		config.ConsAddressString(sdk.ConsAddress(second)),
+		config.ConsAddressString(sdk.ConsAddress(inactive)),
+	}
+	watcher := newBlockWatcher(s, blocks)
+
+	watcher.poll(context.Background())
+	require.Equal(t, float64(6), testutil.ToFloat64(watcher.heightGauge))
+
+	firstLabels := []string{"cosmosvaloper1first", "first"}
+	require.Equal(t, float64(3), testutil.ToFloat64(watcher.signedCounter.WithLabelValues(firstLabels...)))
+	require.Equal(t, float64(1), testutil.ToFloat64(watcher.missedCounter.WithLabelValues(firstLabels...)))
+	require.Equal(t, float64(0), testutil.ToFloat64(watcher.streakGauge.WithLabelValues(firstLabels...)))
+	require.Equal(t, 0.75, testutil.ToFloat64(watcher.uptimeGauge.WithLabelValues(firstLabels...)))
+
+	// not in the set, nothing recorded
+	require.Equal(t, 2, testutil.CollectAndCount(watcher.uptimeGauge))
+
+	// blocks 3 to 6
+	require.Equal(t, float64(1), testutil.ToFloat64(watcher.proposedCounter.WithLabelValues(firstLabels...)))
+	require.Equal(t, float64(1), testutil.ToFloat64(watcher.expectedProposedCounter.WithLabelValues(firstLabels...)))
+	require.Equal(t, 0.25, testutil.ToFloat64(watcher.proposalShareGauge.WithLabelValues(firstLabels...)))
+	require.Equal(t, float64(0), testutil.ToFloat64(watcher.proposalShareGauge.WithLabelValues("cosmosvaloper1inactive", "n/a")))
+
+	// the new blocks only
+	blocks.latest = 9
+	blocks.signers[7] = []cmttypes.Address{second}
+	blocks.signers[8] = []cmttypes.Address{second}
+	blocks.signers[9] = []cmttypes.Address{second}
+	watcher.poll(context.Background())
+
+	require.Equal(t, float64(3), testutil.ToFloat64(watcher.signedCounter.WithLabelValues(firstLabels...)))
+	require.Equal(t, float64(4), testutil.ToFloat64(watcher.missedCounter.WithLabelValues(firstLabels...)))
+	require.Equal(t, float64(3), testutil.ToFloat64(watcher.streakGauge.WithLabelValues(firstLabels...)))
+	require.Equal(t, 0.25, testutil.ToFloat64(watcher.uptimeGauge.WithLabelValues(firstLabels...)))
+
+	secondLabels := []string{"cosmosvaloper1second", "second"}
+	require.Equal(t, float64(7), testutil.ToFloat64(watcher.signedCounter.WithLabelValues(secondLabels...)))
+	require.Equal(t, float64(1), testutil.ToFloat64(watcher.uptimeGauge.WithLabelValues(secondLabels...)))
+	require.Equal(t, float64(5), testutil.ToFloat64(watcher.proposedCounter.WithLabelValues(secondLabels...)))
+	require.Equal(t, 5.25, testutil.ToFloat64(watcher.expectedProposedCounter.WithLabelValues(secondLabels...)))
+
+	// fetched for the first block, then once the hash of the set is known
+	require.Equal(t, 2, blocks.setQueries)
+
+	// no longer monitored
+	s.Validators = s.Validators[1:]
+	s.ValidatorCons = s.ValidatorCons[1:]
+	watcher.poll(context.Background())
+	require.Equal(t, 1, testutil.CollectAndCount(watcher.streakGauge))
+	require.Equal(t, 2, testutil.CollectAndCount(watcher.proposalShareGauge))
+
+	// the consensus address of the second validator changed, it is followed from the next block
+	s.ValidatorCons[0] = config.ConsAddressString(sdk.ConsAddress(first))
+	watcher.resolved = time.Time{}
+	blocks.latest = 10
+	blocks.signers[10] = []cmttypes.Address{second}
+	watcher.poll(context.Background())
+	require.Equal(t, float64(0), testutil.ToFloat64(watcher.signedCounter.WithLabelValues(secondLabels...)))
+	require.Equal(t, float64(1), testutil.ToFloat64(watcher.missedCounter.WithLabelValues(secondLabels...)))
+	require.Equal(t, first.String(), watcher.tracked["cosmosvaloper1second"].consAddress)
+}
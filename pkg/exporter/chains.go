@@ -0,0 +1,321 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+)
+
+// ChainConfig is one entry of the --chains file. The keys are named after the
+// corresponding command line flags, e.g.
+//
+//	chains:
+//	  - name: cosmoshub
+//	    node: cosmoshub-grpc.example.com:9090
+//	    tendermint-rpc: http://cosmoshub-rpc.example.com:26657
+//	    bech-prefix: cosmos
+//	    denom: atom
+//	    validators: [cosmosvaloper1...]
+//	    wallets: [cosmos1...]
+//	    params: true
+//
+// Toggles left out of an entry keep the value given on the command line, the flags
+// added by the extensions are shared by all chains.
+type ChainConfig struct {
+	Name          string   `mapstructure:"name"`
+	Node          []string `mapstructure:"node"`
+	ExternalNode  string   `mapstructure:"external-node"`
+	TendermintRPC string   `mapstructure:"tendermint-rpc"`
+
+	NodeTLS                           bool     `mapstructure:"node-tls"`
+	NodeTLSCAFile                     string   `mapstructure:"node-tls-ca-file"`
+	NodeTLSCertFile                   string   `mapstructure:"node-tls-cert-file"`
+	NodeTLSKeyFile                    string   `mapstructure:"node-tls-key-file"`
+	NodeTLSServerName                 string   `mapstructure:"node-tls-server-name"`
+	NodeTLSInsecureSkipVerify         bool     `mapstructure:"node-tls-insecure-skip-verify"`
+	NodeHeader                        []string `mapstructure:"node-header"`
+	ExternalNodeTLS                   bool     `mapstructure:"external-node-tls"`
+	ExternalNodeTLSCAFile             string   `mapstructure:"external-node-tls-ca-file"`
+	ExternalNodeTLSCertFile           string   `mapstructure:"external-node-tls-cert-file"`
+	ExternalNodeTLSKeyFile            string   `mapstructure:"external-node-tls-key-file"`
+	ExternalNodeTLSServerName         string   `mapstructure:"external-node-tls-server-name"`
+	ExternalNodeTLSInsecureSkipVerify bool     `mapstructure:"external-node-tls-insecure-skip-verify"`
+	ExternalNodeHeader                []string `mapstructure:"external-node-header"`
+
+	Denom            string   `mapstructure:"denom"`
+	DenomCoefficient float64  `mapstructure:"denom-coefficient"`
+	DenomExponent    uint64   `mapstructure:"denom-exponent"`
+	DenomMetadata    []string `mapstructure:"denom-metadata"`
+	CoinGeckoIDs     []string `mapstructure:"coingecko-ids"`
+	PriceFile        string   `mapstructure:"price-file"`
+
+	Prefix                    string `mapstructure:"bech-prefix"`
+	AccountPrefix             string `mapstructure:"bech-account-prefix"`
+	AccountPubkeyPrefix       string `mapstructure:"bech-account-pubkey-prefix"`
+	ValidatorPrefix           string `mapstructure:"bech-validator-prefix"`
+	ValidatorPubkeyPrefix     string `mapstructure:"bech-validator-pubkey-prefix"`
+	ConsensusNodePrefix       string `mapstructure:"bech-consensus-node-prefix"`
+	ConsensusNodePubkeyPrefix string `mapstructure:"bech-consensus-node-pubkey-prefix"`
+
+	Validators    []string `mapstructure:"validators"`
+	ValidatorCons []string `mapstructure:"validatorcons"`
+	Wallets       []string `mapstructure:"wallets"`
+	Extensions    []string `mapstructure:"extensions"`
+	LCD           string   `mapstructure:"lcd"`
+
+	SingleReq bool `mapstructure:"single"`
+	Poll      bool `mapstructure:"poll"`
+	// BlockWatcher is a toggle, its window and interval are shared by all chains
+	BlockWatcher bool `mapstructure:"block-watcher"`
+	Params       bool `mapstructure:"params"`
+	Proposals    bool `mapstructure:"proposals"`
+	Upgrades     bool `mapstructure:"upgrades"`
+	Votes        bool `mapstructure:"votes"`
+	PropV1       bool `mapstructure:"propv1"`
+	TokenPrice   bool `mapstructure:"price"`
+	Oracle       bool `mapstructure:"oracle"`
+}
+
+// LoadChains reads the --chains file. Every chain starts from the toggles of defaults,
+// while the node, denom, prefixes, validators and wallets have to be set per chain.
+func LoadChains(path string, defaults *ServiceConfig) ([]ChainConfig, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	var entries []map[string]interface{}
+	if err := v.UnmarshalKey("chains", &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no chains in %s", path)
+	}
+
+	chains := make([]ChainConfig, 0, len(entries))
+	seen := make(map[string]bool)
+	for i, entry := range entries {
+		chain := ChainConfig{
+			DenomCoefficient: 1,
+			Prefix:           defaults.Prefix,
+			// a chain is served on /metrics/<chain>, which is the single mode endpoint
+			SingleReq:    true,
+			Poll:         defaults.Poll,
+			BlockWatcher: defaults.BlockWatcher,
+			Params:       defaults.Params,
+			Proposals:    defaults.Proposals,
+			Upgrades:     defaults.Upgrades,
+			Votes:        defaults.Votes,
+			PropV1:       defaults.PropV1,
+			TokenPrice:   defaults.TokenPrice,
+			Oracle:       defaults.Oracle,
+		}
+
+		// decoding over the defaults only overwrites the keys present in the entry
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			Result:           &chain,
+			ErrorUnused:      true,
+			WeaklyTypedInput: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.Decode(entry); err != nil {
+			return nil, fmt.Errorf("chain #%d: %w", i+1, err)
+		}
+
+		switch {
+		case chain.Name == "":
+			return nil, fmt.Errorf("chain #%d: name is required", i+1)
+		case strings.ContainsAny(chain.Name, "/?#"):
+			return nil, fmt.Errorf("chain %s: name must be usable in an URL path", chain.Name)
+		case seen[chain.Name]:
+			return nil, fmt.Errorf("chain %s: defined more than once", chain.Name)
+		case len(chain.Node) == 0:
+			return nil, fmt.Errorf("chain %s: node is required", chain.Name)
+		}
+		seen[chain.Name] = true
+
+		chains = append(chains, chain)
+	}
+
+	return chains, nil
+}
+
+// ServiceConfig derives the config of the chain from the process wide defaults,
+// e.g. the timeouts, polling intervals and pagination limit.
+func (chain ChainConfig) ServiceConfig(defaults *ServiceConfig) *ServiceConfig {
+	config := *defaults
+	config.ChainsPath = ""
+	config.ChainID = ""
+	config.ConstLabels = nil
+	config.Initia = false
+
+	config.NodeAddresses = chain.Node
+	config.ExternalGrpc = chain.ExternalNode
+	config.TendermintRPC = chain.TendermintRPC
+	config.NodeGrpc = GrpcClientConfig{
+		TLS:                chain.NodeTLS,
+		CAFile:             chain.NodeTLSCAFile,
+		CertFile:           chain.NodeTLSCertFile,
+		KeyFile:            chain.NodeTLSKeyFile,
+		ServerName:         chain.NodeTLSServerName,
+		InsecureSkipVerify: chain.NodeTLSInsecureSkipVerify,
+		Headers:            chain.NodeHeader,
+	}
+	config.ExternalNodeGrpc = GrpcClientConfig{
+		TLS:                chain.ExternalNodeTLS,
+		CAFile:             chain.ExternalNodeTLSCAFile,
+		CertFile:           chain.ExternalNodeTLSCertFile,
+		KeyFile:            chain.ExternalNodeTLSKeyFile,
+		ServerName:         chain.ExternalNodeTLSServerName,
+		InsecureSkipVerify: chain.ExternalNodeTLSInsecureSkipVerify,
+		Headers:            chain.ExternalNodeHeader,
+	}
+
+	config.Denom = chain.Denom
+	config.DenomCoefficient = chain.DenomCoefficient
+	config.DenomExponent = chain.DenomExponent
+	config.DenomMetadata = chain.DenomMetadata
+	config.CoinGeckoIDs = chain.CoinGeckoIDs
+	config.PriceFile = chain.PriceFile
+
+	config.Prefix = chain.Prefix
+	config.AccountPrefix = bechPrefix(chain.AccountPrefix, chain.Prefix, "")
+	config.AccountPubkeyPrefix = bechPrefix(chain.AccountPubkeyPrefix, chain.Prefix, "pub")
+	config.ValidatorPrefix = bechPrefix(chain.ValidatorPrefix, chain.Prefix, "valoper")
+	config.ValidatorPubkeyPrefix = bechPrefix(chain.ValidatorPubkeyPrefix, chain.Prefix, "valoperpub")
+	config.ConsensusNodePrefix = bechPrefix(chain.ConsensusNodePrefix, chain.Prefix, "valcons")
+	config.ConsensusNodePubkeyPrefix = bechPrefix(chain.ConsensusNodePubkeyPrefix, chain.Prefix, "valconspub")
+
+	config.Validators = chain.Validators
+	config.Wallets = chain.Wallets
+	config.ValidatorCons = chain.ValidatorCons
+	config.Extensions = chain.Extensions
+	config.LCD = chain.LCD
+
+	config.SingleReq = chain.SingleReq
+	config.Poll = chain.Poll
+	config.BlockWatcher = chain.BlockWatcher
+	config.Params = chain.Params
+	config.Proposals = chain.Proposals
+	config.Upgrades = chain.Upgrades
+	config.Votes = chain.Votes
+	config.PropV1 = chain.PropV1
+	config.TokenPrice = chain.TokenPrice
+	config.Oracle = chain.Oracle
+
+	return &config
+}
+
+func bechPrefix(override, prefix, suffix string) string {
+	if override != "" {
+		return override
+	}
+	return prefix + suffix
+}
+
+// Chains serves several chains from one process. Each chain has its own Service and is
+// reachable on /metrics/<chain>[/<endpoint>] or /metrics[/<endpoint>]?chain=<chain>.
+type Chains struct {
+	Log      zerolog.Logger
+	services map[string]*Service
+	routes   map[string]map[string]http.HandlerFunc
+}
+
+// NewChains connects to every chain of the --chains file, enabling the extensions
+// each chain lists out of the available ones.
+func NewChains(ctx context.Context, defaults *ServiceConfig, log zerolog.Logger, available []ChainExtension) (*Chains, error) {
+	chainConfigs, err := LoadChains(defaults.ChainsPath, defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Chains{
+		Log:      log,
+		services: make(map[string]*Service),
+		routes:   make(map[string]map[string]http.HandlerFunc),
+	}
+
+	for _, chain := range chainConfigs {
+		config := chain.ServiceConfig(defaults)
+		extensions, err := ResolveExtensions(config.Extensions, available)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("chain %s: %w", chain.Name, err)
+		}
+		for _, ext := range extensions {
+			ext.Configure(config)
+		}
+
+		s := &Service{Log: log.With().Str("chain", chain.Name).Logger()}
+		config.LogConfig(s.Log.Info()).Msg("Adding chain")
+		if err := s.Connect(config); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("chain %s: %w", chain.Name, err)
+		}
+		s.SetChainID(config)
+		s.SetDenom(config)
+		s.SetPrices(config)
+		s.Configure(config)
+		s.Extensions = extensions
+
+		c.services[chain.Name] = s
+		c.routes[chain.Name] = s.Routes(ctx)
+	}
+
+	return c, nil
+}
+
+// Names returns the names of the served chains in alphabetical order.
+func (c *Chains) Names() []string {
+	names := make([]string, 0, len(c.services))
+	for name := range c.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Chains) Close() {
+	for name, s := range c.services {
+		if err := s.Close(); err != nil {
+			c.Log.Error().Str("chain", name).Err(err).Msg("Could not close service client")
+		}
+	}
+}
+
+// Handler dispatches a request below /metrics to the service of the requested chain.
+func (c *Chains) Handler(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/metrics")
+	name := r.URL.Query().Get("chain")
+	if name == "" {
+		var rest string
+		name, rest, _ = strings.Cut(strings.TrimPrefix(endpoint, "/"), "/")
+		endpoint = ""
+		if rest != "" {
+			endpoint = "/" + rest
+		}
+	}
+
+	routes, ok := c.routes[name]
+	if !ok {
+		c.Log.Warn().Str("chain", name).Str("path", r.URL.Path).Msg("Request for unknown chain")
+		http.Error(w, fmt.Sprintf("unknown chain %q, serving: %s", name, strings.Join(c.Names(), ", ")), http.StatusNotFound)
+		return
+	}
+
+	handler, ok := routes[endpoint]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
+}
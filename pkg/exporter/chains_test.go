@@ -0,0 +1,121 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadChains(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chains.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+chains:
+  - name: cosmoshub
+    node: cosmoshub:9090
+    bech-prefix: cosmos
+    validators: [cosmosvaloper1abc]
+    params: false
+  - name: iris
+    node: [iris-1:9090, iris-2:9090]
+    bech-prefix: iaa
+    bech-validator-prefix: iva
+`), 0o600))
+
+	defaults := &ServiceConfig{Prefix: "persistence", Params: true, Limit: 500}
+	chains, err := LoadChains(path, defaults)
+	require.NoError(t, err)
+	require.Len(t, chains, 2)
+
+	hub := chains[0].ServiceConfig(defaults)
+	require.Equal(t, []string{"cosmoshub:9090"}, hub.NodeAddresses)
+	require.Equal(t, "cosmosvaloper", hub.ValidatorPrefix)
+	require.Equal(t, []string{"cosmosvaloper1abc"}, hub.Validators)
+	require.False(t, hub.Params)
+	require.True(t, hub.SingleReq)
+	require.Equal(t, uint64(500), hub.Limit)
+
+	iris := chains[1].ServiceConfig(defaults)
+	require.Equal(t, "iaa", iris.AccountPrefix)
+	require.Equal(t, "iva", iris.ValidatorPrefix)
+	require.Equal(t, "iaavalcons", iris.ConsensusNodePrefix)
+	require.True(t, iris.Params)
+	require.Equal(t, []string{"iris-1:9090", "iris-2:9090"}, iris.NodeAddresses)
+}
+
+func TestLoadChainsErrors(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Config string
+	}{
+		{Name: "no chains", Config: "chains: []"},
+		{Name: "missing name", Config: "chains:\n  - node: a:9090"},
+		{Name: "missing node", Config: "chains:\n  - name: a"},
+		{Name: "duplicate", Config: "chains:\n  - {name: a, node: a:9090}\n  - {name: a, node: b:9090}"},
+		{Name: "unknown key", Config: "chains:\n  - {name: a, node: a:9090, nod: b}"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "chains.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(tt.Config), 0o600))
+
+		_, err := LoadChains(path, &ServiceConfig{})
+		require.Error(t, err, tt.Name)
+	}
+}
+
+func TestBech32PerChain(t *testing.T) {
+	hub := &ServiceConfig{AccountPrefix: "cosmos", ValidatorPrefix: "cosmosvaloper"}
+	osmo := &ServiceConfig{AccountPrefix: "osmo", ValidatorPrefix: "osmovaloper"}
+
+	address := []byte("01234567890123456789")
+	encoded := hub.AccAddressString(address)
+
+	decoded, err := hub.AccAddressFromBech32(encoded)
+	require.NoError(t, err)
+	require.Equal(t, address, decoded.Bytes())
+
+	_, err = osmo.AccAddressFromBech32(encoded)
+	require.Error(t, err)
+
+	require.Equal(t, "", osmo.ValAddressString(nil))
+}
+
+func TestChainsHandler(t *testing.T) {
+	var served string
+	route := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { served = name }
+	}
+	c := &Chains{
+		Log:      zerolog.Nop(),
+		services: map[string]*Service{"hub": {}},
+		routes: map[string]map[string]http.HandlerFunc{
+			"hub": {"": route("single"), "/validator": route("validator")},
+		},
+	}
+
+	tests := []struct {
+		Path     string
+		Expected string
+		Status   int
+	}{
+		{Path: "/metrics/hub", Expected: "single", Status: http.StatusOK},
+		{Path: "/metrics/hub/validator?address=x", Expected: "validator", Status: http.StatusOK},
+		{Path: "/metrics?chain=hub", Expected: "single", Status: http.StatusOK},
+		{Path: "/metrics/validator?chain=hub", Expected: "validator", Status: http.StatusOK},
+		{Path: "/metrics/osmosis", Status: http.StatusNotFound},
+		{Path: "/metrics/hub/wallet", Status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		served = ""
+		recorder := httptest.NewRecorder()
+		c.Handler(recorder, httptest.NewRequest(http.MethodGet, tt.Path, nil))
+		require.Equal(t, tt.Status, recorder.Code, tt.Path)
+		require.Equal(t, tt.Expected, served, tt.Path)
+	}
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// CommissionTracker remembers the commission of every validator seen by the validator and
+// validators handlers, and counts the changes between two scrapes. Its counter lives for the
+// whole process and is served on every endpoint.
+type CommissionTracker struct {
+	log zerolog.Logger
+
+	mu   sync.Mutex
+	seen map[string]commissionState
+
+	changesCounter *prometheus.CounterVec
+}
+
+// commissionState is the last commission seen for a validator.
+type commissionState struct {
+	moniker    string
+	rate       string
+	updateTime time.Time
+	changes    float64
+}
+
+func NewCommissionTracker(reg prometheus.Registerer, config *ServiceConfig, log zerolog.Logger) *CommissionTracker {
+	t := &CommissionTracker{
+		log:  log,
+		seen: make(map[string]commissionState),
+		changesCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_validator_commission_changes_total",
+				Help:        "Number of commission changes of the Cosmos-based blockchain validator observed since the exporter started",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+	}
+	reg.MustRegister(t.changesCounter)
+
+	return t
+}
+
+// Observe compares the commission of validator with the one of the previous scrape. The first
+// scrape only records it, so that the counter does not count the commissions set before the
+// exporter started.
+func (t *CommissionTracker) Observe(validator stakingtypes.Validator) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := commissionState{
+		moniker:    validator.Description.Moniker,
+		rate:       validator.Commission.CommissionRates.Rate.String(),
+		updateTime: validator.Commission.UpdateTime,
+	}
+
+	previous, ok := t.seen[validator.OperatorAddress]
+	if ok {
+		current.changes = previous.changes
+		if previous.moniker != current.moniker {
+			// keep a single series per validator
+			t.changesCounter.DeleteLabelValues(validator.OperatorAddress, previous.moniker)
+			t.changesCounter.WithLabelValues(validator.OperatorAddress, current.moniker).Add(current.changes)
+		}
+	}
+
+	counter := t.changesCounter.WithLabelValues(validator.OperatorAddress, current.moniker)
+	if ok && (previous.rate != current.rate || !previous.updateTime.Equal(current.updateTime)) {
+		current.changes++
+		counter.Inc()
+
+		t.log.Info().
+			Str("address", validator.OperatorAddress).
+			Str("moniker", current.moniker).
+			Str("previous-rate", previous.rate).
+			Str("rate", current.rate).
+			Msg("Validator commission changed")
+	}
+
+	t.seen[validator.OperatorAddress] = current
+}
@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"cosmossdk.io/math"
+
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestCommissionTracker(t *testing.T) {
+	tracker := NewCommissionTracker(prometheus.NewRegistry(), &ServiceConfig{}, zerolog.Nop())
+	validator, _ := fakeValidator(t, "first", 100, stakingtypes.Bonded, false)
+	setCommission := func(rate string, updateTime int64) {
+		validator.Commission = stakingtypes.NewCommissionWithTime(
+			math.LegacyMustNewDecFromStr(rate),
+			math.LegacyMustNewDecFromStr("0.2"),
+			math.LegacyMustNewDecFromStr("0.01"),
+			time.Unix(updateTime, 0).UTC(),
+		)
+	}
+
+	// the commission set before the start is not a change
+	setCommission("0.05", 1700000000)
+	tracker.Observe(validator)
+	tracker.Observe(validator)
+	require.Equal(t, float64(0), testutil.ToFloat64(tracker.changesCounter.WithLabelValues("first", "first")))
+
+	setCommission("0.06", 1700086400)
+	tracker.Observe(validator)
+	// seen by both the validator and the validators handlers
+	tracker.Observe(validator)
+	require.Equal(t, float64(1), testutil.ToFloat64(tracker.changesCounter.WithLabelValues("first", "first")))
+
+	// changed back to the same rate
+	setCommission("0.06", 1700172800)
+	validator.Description.Moniker = "renamed"
+	tracker.Observe(validator)
+	require.Equal(t, float64(2), testutil.ToFloat64(tracker.changesCounter.WithLabelValues("first", "renamed")))
+	require.Equal(t, 1, testutil.CollectAndCount(tracker.changesCounter))
+
+	// not created without a chain id
+	var missing *CommissionTracker
+	missing.Observe(validator)
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
-	sdk "github.com/cosmos/cosmos-sdk/types"
 	querytypes "github.com/cosmos/cosmos-sdk/types/query"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 )
@@ -23,7 +22,7 @@ func (s *Service) DelegatorHandler(w http.ResponseWriter, r *http.Request) {
 		Logger()
 
 	validatorAddress := r.URL.Query().Get("validator_address")
-	valAddress, err := sdk.ValAddressFromBech32(validatorAddress)
+	valAddress, err := s.Config.ValAddressFromBech32(validatorAddress)
 	if err != nil {
 		sublogger.Error().
 			Str("validator_address", validatorAddress).
@@ -44,6 +43,9 @@ func (s *Service) DelegatorHandler(w http.ResponseWriter, r *http.Request) {
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(delegatorTotalGauge)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "delegator")
 	var wg sync.WaitGroup
 
 	wg.Add(1)
@@ -55,15 +57,17 @@ func (s *Service) DelegatorHandler(w http.ResponseWriter, r *http.Request) {
 		queryStart := time.Now()
 
 		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-		delegatorRes, err := stakingClient.ValidatorDelegations(
-			context.Background(),
-			&stakingtypes.QueryValidatorDelegationsRequest{
-				ValidatorAddr: valAddress.String(),
-				Pagination: &querytypes.PageRequest{
-					Limit: s.Config.Limit,
-				},
-			},
-		)
+		delegators, err := CountAll(ctx, s, "staking.ValidatorDelegations",
+			func(ctx context.Context, page *querytypes.PageRequest) ([]stakingtypes.DelegationResponse, *querytypes.PageResponse, error) {
+				res, err := stakingClient.ValidatorDelegations(
+					ctx,
+					&stakingtypes.QueryValidatorDelegationsRequest{
+						ValidatorAddr: s.Config.ValAddressString(valAddress),
+						Pagination:    page,
+					},
+				)
+				return res.GetDelegationResponses(), res.GetPagination(), err
+			})
 		if err != nil {
 			sublogger.Error().
 				Str("validator_address", validatorAddress).
@@ -79,12 +83,14 @@ func (s *Service) DelegatorHandler(w http.ResponseWriter, r *http.Request) {
 
 		delegatorTotalGauge.With(prometheus.Labels{
 			"validator_address": validatorAddress,
-		}).Set(float64(len(delegatorRes.DelegationResponses)))
+		}).Set(float64(delegators))
 	}()
 
 	wg.Wait()
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	s.FinishCollector(run)
+
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
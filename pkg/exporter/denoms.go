@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// DenomUnit tells how to display a coin: amounts of Base are divided by Coefficient to get
+// amounts of Display.
+type DenomUnit struct {
+	Base        string
+	Display     string
+	Coefficient float64
+}
+
+// DenomRegistry holds the display unit of every coin the chain describes in its bank
+// metadata, plus the ones set with --denom-metadata.
+type DenomRegistry struct {
+	mu    sync.RWMutex
+	units map[string]DenomUnit
+}
+
+func NewDenomRegistry() *DenomRegistry {
+	return &DenomRegistry{units: make(map[string]DenomUnit)}
+}
+
+// Set adds or replaces the unit of unit.Base.
+func (r *DenomRegistry) Set(unit DenomUnit) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.units[unit.Base] = unit
+}
+
+// AddMetadata registers metadata under its display unit, or its base unit when the display
+// unit is not one of its denom units.
+func (r *DenomRegistry) AddMetadata(metadata banktypes.Metadata) {
+	unit := DenomUnit{Base: metadata.Base, Display: metadata.Base, Coefficient: 1}
+	for _, denomUnit := range metadata.DenomUnits {
+		if denomUnit.Denom == metadata.Display {
+			unit.Display = denomUnit.Denom
+			unit.Coefficient = math.Pow10(int(denomUnit.Exponent))
+		}
+	}
+	r.Set(unit)
+}
+
+// Lookup returns the unit of the base denom. Unknown denoms are displayed as they are.
+func (r *DenomRegistry) Lookup(base string) DenomUnit {
+	if r != nil {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		if unit, ok := r.units[base]; ok {
+			return unit
+		}
+	}
+	return DenomUnit{Base: base, Display: base, Coefficient: 1}
+}
+
+// Base returns the base denom displayed as display, or display itself if none is.
+func (r *DenomRegistry) Base(display string) string {
+	if r != nil {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		if _, ok := r.units[display]; ok {
+			return display
+		}
+		for _, unit := range r.units {
+			if unit.Display == display {
+				return unit.Base
+			}
+		}
+	}
+	return display
+}
+
+// Value converts amount, a base unit amount of denom, to display units.
+func (r *DenomRegistry) Value(denom string, amount string) (float64, DenomUnit, error) {
+	unit := r.Lookup(denom)
+	// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, unit, err
+	}
+	return value / unit.Coefficient, unit, nil
+}
+
+// ParseDenomUnit parses a --denom-metadata entry, <base>=<display>:<exponent>.
+func ParseDenomUnit(entry string) (DenomUnit, error) {
+	base, display, found := strings.Cut(entry, "=")
+	if !found || base == "" {
+		return DenomUnit{}, fmt.Errorf("invalid denom metadata %q, expected <base>=<display>:<exponent>", entry)
+	}
+	index := strings.LastIndex(display, ":")
+	if index <= 0 {
+		return DenomUnit{}, fmt.Errorf("invalid denom metadata %q, expected <base>=<display>:<exponent>", entry)
+	}
+	exponent, err := strconv.ParseUint(display[index+1:], 10, 32)
+	if err != nil {
+		return DenomUnit{}, fmt.Errorf("invalid exponent in denom metadata %q: %w", entry, err)
+	}
+	return DenomUnit{Base: base, Display: display[:index], Coefficient: math.Pow10(int(exponent))}, nil
+}
+
+// LoadDenomMetadata returns every denom metadata of the bank module.
+func (s *Service) LoadDenomMetadata(ctx context.Context) ([]banktypes.Metadata, error) {
+	bankClient := banktypes.NewQueryClient(s.GrpcConn)
+	return Paginate(ctx, s, "bank.DenomsMetadata",
+		func(ctx context.Context, page *querytypes.PageRequest) ([]banktypes.Metadata, *querytypes.PageResponse, error) {
+			res, err := bankClient.DenomsMetadata(
+				ctx,
+				&banktypes.QueryDenomsMetadataRequest{Pagination: page},
+			)
+			return res.GetMetadatas(), res.GetPagination(), err
+		})
+}
@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// fakeBank serves the denoms metadata of a fake chain, one per page.
+type fakeBank struct {
+	banktypes.UnimplementedQueryServer
+
+	metadatas []banktypes.Metadata
+}
+
+func (f *fakeBank) DenomsMetadata(_ context.Context, req *banktypes.QueryDenomsMetadataRequest) (*banktypes.QueryDenomsMetadataResponse, error) {
+	index := 0
+	if req.Pagination != nil && len(req.Pagination.Key) > 0 {
+		index = int(req.Pagination.Key[0])
+	}
+	pagination := &querytypes.PageResponse{}
+	if index+1 < len(f.metadatas) {
+		pagination.NextKey = []byte{byte(index + 1)}
+	}
+	return &banktypes.QueryDenomsMetadataResponse{
+		Metadatas:  f.metadatas[index : index+1],
+		Pagination: pagination,
+	}, nil
+}
+
+func denomMetadata(base, display string, exponent uint32) banktypes.Metadata {
+	return banktypes.Metadata{
+		Base:    base,
+		Display: display,
+		DenomUnits: []*banktypes.DenomUnit{
+			{Denom: base, Exponent: 0},
+			{Denom: display, Exponent: exponent},
+		},
+	}
+}
+
+func TestSetDenom(t *testing.T) {
+	bank := &fakeBank{metadatas: []banktypes.Metadata{
+		denomMetadata("uother", "other", 3),
+		denomMetadata("ustake", "stake", 6),
+		denomMetadata("ibc/27394FB0", "atom", 6),
+	}}
+	s := startFakeChain(t, func(server *grpc.Server) {
+		banktypes.RegisterQueryServer(server, bank)
+		stakingtypes.RegisterQueryServer(server, &fakeStaking{bondDenom: "ustake"})
+	})
+	s.Config.DenomCoefficient = 1
+	s.Config.DenomMetadata = []string{"ibc/27394FB0=ATOM:0", "cw20:juno1abc=token:4"}
+
+	s.SetDenom(s.Config)
+
+	// the staking denom is the default --denom, not the first metadata
+	require.Equal(t, "stake", s.Config.Denom)
+	require.InDelta(t, 1e6, s.Config.DenomCoefficient, 0)
+	require.Equal(t, "ustake", s.Denoms.Base("stake"))
+
+	value, unit, err := s.Denoms.Value("uother", "1500")
+	require.NoError(t, err)
+	require.InDelta(t, 1.5, value, 1e-9)
+	require.Equal(t, "other", unit.Display)
+
+	// --denom-metadata overrides the chain metadata
+	value, unit, err = s.Denoms.Value("ibc/27394FB0", "42")
+	require.NoError(t, err)
+	require.InDelta(t, 42, value, 0)
+	require.Equal(t, "ATOM", unit.Display)
+
+	value, unit, err = s.Denoms.Value("cw20:juno1abc", "20000")
+	require.NoError(t, err)
+	require.InDelta(t, 2, value, 0)
+	require.Equal(t, "token", unit.Display)
+
+	// unknown denoms are not scaled
+	value, unit, err = s.Denoms.Value("unknown", "7")
+	require.NoError(t, err)
+	require.InDelta(t, 7, value, 0)
+	require.Equal(t, "unknown", unit.Display)
+}
+
+func TestSetDenomProvidedByUser(t *testing.T) {
+	s := startFakeChain(t, func(server *grpc.Server) {
+		banktypes.RegisterQueryServer(server, &fakeBank{metadatas: []banktypes.Metadata{denomMetadata("uosmo", "osmo", 6)}})
+		stakingtypes.RegisterQueryServer(server, &fakeStaking{bondDenom: "uosmo"})
+	})
+	s.Config.Denom = "uosmo"
+	s.Config.DenomCoefficient = 1
+	s.Config.DenomExponent = 3
+
+	s.SetDenom(s.Config)
+
+	require.InDelta(t, 1e3, s.Config.DenomCoefficient, 0)
+	value, unit, err := s.Denoms.Value("uosmo", "5000")
+	require.NoError(t, err)
+	require.InDelta(t, 5, value, 0)
+	require.Equal(t, "uosmo", unit.Display)
+}
+
+func TestParseDenomUnit(t *testing.T) {
+	unit, err := ParseDenomUnit("factory/osmo1abc/token=token:18")
+	require.NoError(t, err)
+	require.Equal(t, DenomUnit{Base: "factory/osmo1abc/token", Display: "token", Coefficient: 1e18}, unit)
+
+	for _, entry := range []string{"uatom", "=atom:6", "uatom=atom", "uatom=atom:six"} {
+		_, err := ParseDenomUnit(entry)
+		require.Error(t, err, entry)
+	}
+}
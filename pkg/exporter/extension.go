@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ChainExtension adds the metrics of a chain specific module (e.g. an oracle) to the core exporter.
+// Extensions are compiled into the binary and enabled per chain with --extensions.
+type ChainExtension interface {
+	// Name is the value used in --extensions.
+	Name() string
+	// RegisterFlags adds the flags of the extension. Called for every known extension before parsing.
+	RegisterFlags(flags *pflag.FlagSet)
+	// Configure adjusts the config of a chain using the extension, before connecting to it.
+	Configure(config *ServiceConfig)
+	// RegisterMetrics registers the metrics of the extension for one collection.
+	RegisterMetrics(reg prometheus.Registerer, config *ServiceConfig) ExtensionMetrics
+	// Routes returns the extra handlers of the extension keyed by their path below /metrics.
+	Routes(s *Service) map[string]http.HandlerFunc
+}
+
+// ExtensionMetrics collect the metrics of an extension. Like the core collectors, they add
+// their queries to wg and return without waiting for them.
+type ExtensionMetrics interface {
+	// CollectChain is called once per collection.
+	CollectChain(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, s *Service)
+	// CollectValidator is called for every monitored validator.
+	CollectValidator(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, s *Service, validator sdk.ValAddress)
+	// CollectWallet is called for every monitored wallet.
+	CollectWallet(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, s *Service, wallet sdk.AccAddress)
+}
+
+// ResolveExtensions picks the extensions named in --extensions out of the available ones.
+func ResolveExtensions(names []string, available []ChainExtension) ([]ChainExtension, error) {
+	byName := make(map[string]ChainExtension, len(available))
+	known := make([]string, 0, len(available))
+	for _, ext := range available {
+		byName[ext.Name()] = ext
+		known = append(known, ext.Name())
+	}
+
+	extensions := make([]ChainExtension, 0, len(names))
+	for _, name := range names {
+		ext, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown extension %q, available: %s", name, strings.Join(known, ", "))
+		}
+		extensions = append(extensions, ext)
+	}
+	return extensions, nil
+}
+
+func (s *Service) collectExtensions(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, reg prometheus.Registerer, _ map[string]float64) {
+	for _, ext := range s.Extensions {
+		metrics := ext.RegisterMetrics(reg, s.Config)
+		metrics.CollectChain(ctx, wg, sublogger, s)
+
+		for _, validator := range s.Validators {
+			valAddress, err := s.Config.ValAddressFromBech32(validator)
+			if err != nil {
+				sublogger.Error().
+					Str("address", validator).
+					Err(err).
+					Msg("Could not get validator address")
+				continue
+			}
+			sublogger.Debug().
+				Str("address", validator).
+				Str("extension", ext.Name()).
+				Msg("Fetching extension validator details")
+			metrics.CollectValidator(ctx, wg, sublogger, s, valAddress)
+		}
+
+		for _, wallet := range s.Wallets {
+			accAddress, err := s.Config.AccAddressFromBech32(wallet)
+			if err != nil {
+				sublogger.Error().
+					Str("address", wallet).
+					Err(err).
+					Msg("Could not get wallet address")
+				continue
+			}
+			metrics.CollectWallet(ctx, wg, sublogger, s, accAddress)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+type testExtension struct{ name string }
+
+func (e testExtension) Name() string                                  { return e.name }
+func (e testExtension) RegisterFlags(_ *pflag.FlagSet)                {}
+func (e testExtension) Configure(_ *ServiceConfig)                    {}
+func (e testExtension) Routes(_ *Service) map[string]http.HandlerFunc { return nil }
+func (e testExtension) RegisterMetrics(_ prometheus.Registerer, _ *ServiceConfig) ExtensionMetrics {
+	return nil
+}
+
+func TestResolveExtensions(t *testing.T) {
+	available := []ChainExtension{testExtension{name: "sei"}, testExtension{name: "kujira"}}
+
+	extensions, err := ResolveExtensions([]string{"kujira", " sei"}, available)
+	require.NoError(t, err)
+	require.Len(t, extensions, 2)
+	require.Equal(t, "kujira", extensions[0].Name())
+	require.Equal(t, "sei", extensions[1].Name())
+
+	extensions, err = ResolveExtensions(nil, available)
+	require.NoError(t, err)
+	require.Empty(t, extensions)
+
+	_, err = ResolveExtensions([]string{"osmosis"}, available)
+	require.ErrorContains(t, err, "available: sei, kujira")
+}
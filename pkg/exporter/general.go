@@ -4,7 +4,6 @@ import (
 	"context"
 	"math/big"
 	"net/http"
-	"strconv"
 	"sync"
 	"time"
 
@@ -14,14 +13,13 @@ import (
 	"github.com/rs/zerolog"
 
 	tmservice "github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/query"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
 	govv1 "github.com/cosmos/cosmos-sdk/x/gov/types/v1"
 	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
-
-	"github.com/pfc-developer/cosmos-exporter/pkg/cosmosdirectory"
 )
 
 type GeneralMetrics struct {
@@ -162,7 +160,7 @@ func NewGeneralExtendedMetrics(reg prometheus.Registerer, config *ServiceConfig)
 				Help:        "Community pool",
 				ConstLabels: config.ConstLabels,
 			},
-			[]string{"denom"},
+			[]string{"denom", "display_denom", "base_denom", "trace_path"},
 		),
 		supplyTotalGauge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -170,7 +168,7 @@ func NewGeneralExtendedMetrics(reg prometheus.Registerer, config *ServiceConfig)
 				Help:        "Total supply",
 				ConstLabels: config.ConstLabels,
 			},
-			[]string{"denom"},
+			[]string{"denom", "display_denom", "base_denom", "trace_path"},
 		),
 	}
 	reg.MustRegister(m.communityPoolGauge)
@@ -179,20 +177,9 @@ func NewGeneralExtendedMetrics(reg prometheus.Registerer, config *ServiceConfig)
 	return m
 }
 
-func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *GeneralMetrics, s *Service, config *ServiceConfig) {
-	if config.TokenPrice {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			chain, err := cosmosdirectory.GetChainByChainID(config.ChainID)
-			if err != nil {
-				sublogger.Error().Err(err).Msg("Could not get chain information")
-				return
-			}
-
-			price := chain.GetPriceUSD()
-			metrics.tokenPrice.Set(price)
-		}()
+func GetGeneralMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *GeneralMetrics, s *Service, config *ServiceConfig, prices map[string]float64) {
+	if price, ok := prices[s.Denoms.Base(config.Denom)]; ok {
+		metrics.tokenPrice.Set(price)
 	}
 
 	wg.Add(1)
@@ -202,7 +189,7 @@ func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *G
 
 		queryStart := time.Now()
 
-		latest, err := s.GetLatestBlock(s.GrpcConn)
+		latest, err := s.GetLatestBlock(ctx, s.GrpcConn)
 		if err != nil {
 			sublogger.Error().Err(err).Msg("Could not get latest block height")
 			return
@@ -222,7 +209,7 @@ func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *G
 
 			queryStart := time.Now()
 
-			latestExternal, err := s.GetLatestBlock(s.ExternalGrpcConn)
+			latestExternal, err := s.GetLatestBlock(ctx, s.ExternalGrpcConn)
 			if err != nil {
 				sublogger.Error().Err(err).Msg("Could not get latest block height (external)")
 				return
@@ -246,7 +233,7 @@ func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *G
 		serviceClient := tmservice.NewServiceClient(s.GrpcConn)
 
 		response, err := serviceClient.GetSyncing(
-			context.Background(),
+			ctx,
 			&tmservice.GetSyncingRequest{},
 		)
 		if err != nil {
@@ -276,7 +263,7 @@ func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *G
 
 			stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
 			response, err := stakingClient.Pool(
-				context.Background(),
+				ctx,
 				&stakingtypes.QueryPoolRequest{},
 			)
 			if err != nil {
@@ -309,7 +296,7 @@ func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *G
 
 		serviceClient := tmservice.NewServiceClient(s.GrpcConn)
 		response, err := serviceClient.GetNodeInfo(
-			context.Background(),
+			ctx,
 			&tmservice.GetNodeInfoRequest{},
 		)
 		if err != nil {
@@ -347,7 +334,7 @@ func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *G
 
 				mintClient := minttypes.NewQueryClient(s.grpcConn)
 				response, err := mintClient.Inflation(
-					context.Background(),
+					ctx,
 					&minttypes.QueryInflationRequest{},
 				)
 				if err != nil {
@@ -377,7 +364,7 @@ func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *G
 
 			mintClient := minttypes.NewQueryClient(s.grpcConn)
 			response, err := mintClient.AnnualProvisions(
-				context.Background(),
+				ctx,
 				&minttypes.QueryAnnualProvisionsRequest{},
 			)
 			if err != nil {
@@ -409,15 +396,20 @@ func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *G
 			sublogger.Debug().Msg("Started querying global gov V1 params")
 
 			govClient := govv1.NewQueryClient(s.GrpcConn)
-			proposals, err := govClient.Proposals(context.Background(), &govv1.QueryProposalsRequest{
-				ProposalStatus: govv1.StatusVotingPeriod,
-			})
+			proposals, err := Paginate(ctx, s, "gov.Proposals",
+				func(ctx context.Context, page *query.PageRequest) ([]*govv1.Proposal, *query.PageResponse, error) {
+					res, err := govClient.Proposals(ctx, &govv1.QueryProposalsRequest{
+						ProposalStatus: govv1.StatusVotingPeriod,
+						Pagination:     page,
+					})
+					return res.GetProposals(), res.GetPagination(), err
+				})
 			if err != nil {
 				sublogger.Error().
 					Err(err).
 					Msg("Could not get active proposals v1 (general)")
 			}
-			proposalsCount := len(proposals.GetProposals())
+			proposalsCount := len(proposals)
 			metrics.govVotingPeriodProposals.Set(float64(proposalsCount))
 		}()
 	} else {
@@ -428,22 +420,27 @@ func GetGeneralMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *G
 			sublogger.Debug().Msg("Started querying global gov v1beta1 params")
 
 			govClient := govtypes.NewQueryClient(s.GrpcConn)
-			proposals, err := govClient.Proposals(context.Background(), &govtypes.QueryProposalsRequest{
-				ProposalStatus: govtypes.StatusVotingPeriod,
-			})
+			proposals, err := Paginate(ctx, s, "gov.Proposals",
+				func(ctx context.Context, page *query.PageRequest) ([]govtypes.Proposal, *query.PageResponse, error) {
+					res, err := govClient.Proposals(ctx, &govtypes.QueryProposalsRequest{
+						ProposalStatus: govtypes.StatusVotingPeriod,
+						Pagination:     page,
+					})
+					return res.GetProposals(), res.GetPagination(), err
+				})
 			if err != nil {
 				sublogger.Error().
 					Err(err).
 					Msg("Could not get active proposals (v1beta1)")
 			}
 
-			proposalsCount := len(proposals.GetProposals())
+			proposalsCount := len(proposals)
 			metrics.govVotingPeriodProposals.Set(float64(proposalsCount))
 		}()
 	}
 }
 
-func GetGeneralExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *GeneralExtendedMetrics, s *Service, config *ServiceConfig) {
+func GetGeneralExtendedMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *GeneralExtendedMetrics, s *Service, config *ServiceConfig) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -452,7 +449,7 @@ func GetGeneralExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, me
 
 		distributionClient := distributiontypes.NewQueryClient(s.GrpcConn)
 		response, err := distributionClient.CommunityPool(
-			context.Background(),
+			ctx,
 			&distributiontypes.QueryCommunityPoolRequest{},
 		)
 		if err != nil {
@@ -464,15 +461,25 @@ func GetGeneralExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, me
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying distribution community pool")
 
+		denoms := make([]string, 0, len(response.Pool))
+		for _, coin := range response.Pool {
+			denoms = append(denoms, coin.Denom)
+		}
+		s.ResolveDenomTraces(ctx, denoms)
+
 		for _, coin := range response.Pool {
-			if value, err := strconv.ParseFloat(coin.Amount.String(), 64); err != nil {
+			if value, unit, err := s.Denoms.Value(coin.Denom, coin.Amount.String()); err != nil {
 				sublogger.Error().
 					Err(err).
 					Msg("Could not get community pool coin")
 			} else {
+				baseDenom, tracePath := s.DenomTrace(ctx, coin.Denom)
 				metrics.communityPoolGauge.With(prometheus.Labels{
-					"denom": config.Denom,
-				}).Set(value / config.DenomCoefficient)
+					"denom":         coin.Denom,
+					"display_denom": unit.Display,
+					"base_denom":    baseDenom,
+					"trace_path":    tracePath,
+				}).Set(value)
 			}
 		}
 	}()
@@ -484,42 +491,43 @@ func GetGeneralExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, me
 		queryStart := time.Now()
 
 		bankClient := banktypes.NewQueryClient(s.GrpcConn)
-		response, err := bankClient.TotalSupply(
-			context.Background(),
-			&banktypes.QueryTotalSupplyRequest{},
-		)
-		for {
-			if err != nil {
-				sublogger.Error().Err(err).Msg("Could not get bank total supply")
-				return
-			}
+		supply, err := Paginate(ctx, s, "bank.TotalSupply",
+			func(ctx context.Context, page *query.PageRequest) ([]sdk.Coin, *query.PageResponse, error) {
+				res, err := bankClient.TotalSupply(
+					ctx,
+					&banktypes.QueryTotalSupplyRequest{Pagination: page},
+				)
+				return res.GetSupply(), res.GetPagination(), err
+			})
+		if err != nil {
+			sublogger.Error().Err(err).Msg("Could not get bank total supply")
+			return
+		}
 
-			sublogger.Debug().
-				Float64("request-time", time.Since(queryStart).Seconds()).
-				Msg("Finished querying bank total supply")
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying bank total supply")
 
-			for _, coin := range response.Supply {
-				if value, err := strconv.ParseFloat(coin.Amount.String(), 64); err != nil {
-					sublogger.Error().
-						Err(err).
-						Msg("Could not get total supply")
-				} else {
-					metrics.supplyTotalGauge.With(prometheus.Labels{
-						"denom": coin.GetDenom(),
-					}).Set(value)
-				}
-			}
-			if response.Pagination.NextKey == nil {
-				break
+		denoms := make([]string, 0, len(supply))
+		for _, coin := range supply {
+			denoms = append(denoms, coin.GetDenom())
+		}
+		s.ResolveDenomTraces(ctx, denoms)
+
+		for _, coin := range supply {
+			if value, unit, err := s.Denoms.Value(coin.GetDenom(), coin.Amount.String()); err != nil {
+				sublogger.Error().
+					Err(err).
+					Msg("Could not get total supply")
+			} else {
+				baseDenom, tracePath := s.DenomTrace(ctx, coin.GetDenom())
+				metrics.supplyTotalGauge.With(prometheus.Labels{
+					"denom":         coin.GetDenom(),
+					"display_denom": unit.Display,
+					"base_denom":    baseDenom,
+					"trace_path":    tracePath,
+				}).Set(value)
 			}
-			response, err = bankClient.TotalSupply(
-				context.Background(),
-				&banktypes.QueryTotalSupplyRequest{
-					Pagination: &query.PageRequest{
-						Key: response.Pagination.NextKey,
-					},
-				},
-			)
 		}
 	}()
 }
@@ -535,14 +543,19 @@ func (s *Service) GeneralHandler(w http.ResponseWriter, r *http.Request) {
 	generalMetrics := NewGeneralMetrics(registry, s.Config)
 	generalExtendedMetrics := NewGeneralExtendedMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "general")
 	var wg sync.WaitGroup
 
-	GetGeneralMetrics(&wg, &sublogger, generalMetrics, s, s.Config)
-	GetGeneralExtendedMetrics(&wg, &sublogger, generalExtendedMetrics, s, s.Config)
+	GetGeneralMetrics(ctx, &wg, &sublogger, generalMetrics, s, s.Config, s.PricesUSD(ctx, &sublogger))
+	GetGeneralExtendedMetrics(ctx, &wg, &sublogger, generalExtendedMetrics, s, s.Config)
 
 	wg.Wait()
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	s.FinishCollector(run)
+
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
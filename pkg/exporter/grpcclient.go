@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// GrpcClientConfig holds the transport and authentication settings of an upstream
+// gRPC endpoint, --node or --external-node.
+type GrpcClientConfig struct {
+	// TLS forces TLS, it is otherwise enabled by any other TLS option or a :443 address
+	TLS                bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+	// Headers are "key=value" pairs sent as metadata with every call, e.g. an API key
+	Headers []string
+}
+
+// registerGrpcClientFlags adds the --<prefix>-tls* and --<prefix>-header flags.
+func registerGrpcClientFlags(flags *pflag.FlagSet, prefix string, config *GrpcClientConfig) {
+	flags.BoolVar(&config.TLS, prefix+"-tls", false, "connect to --"+prefix+" with TLS, on by default on port 443")
+	flags.StringVar(&config.CAFile, prefix+"-tls-ca-file", "", "PEM CA bundle verifying --"+prefix+" instead of the system roots")
+	flags.StringVar(&config.CertFile, prefix+"-tls-cert-file", "", "PEM client certificate presented to --"+prefix)
+	flags.StringVar(&config.KeyFile, prefix+"-tls-key-file", "", "PEM key of --"+prefix+"-tls-cert-file")
+	flags.StringVar(&config.ServerName, prefix+"-tls-server-name", "", "server name expected in the certificate of --"+prefix+", also sent as SNI")
+	flags.BoolVar(&config.InsecureSkipVerify, prefix+"-tls-insecure-skip-verify", false, "do not verify the certificate of --"+prefix)
+	flags.StringSliceVar(&config.Headers, prefix+"-header", nil, "gRPC metadata sent to --"+prefix+" with every call, as key=value")
+}
+
+func (config GrpcClientConfig) tlsEnabled(address string) bool {
+	return config.TLS ||
+		config.CAFile != "" ||
+		config.CertFile != "" ||
+		config.ServerName != "" ||
+		config.InsecureSkipVerify ||
+		strings.Contains(address, ":443")
+}
+
+// DialOptions returns the transport credentials and metadata options to dial address.
+func (config GrpcClientConfig) DialOptions(address string) ([]grpc.DialOption, error) {
+	creds := insecure.NewCredentials()
+	if config.tlsEnabled(address) {
+		tlsConfig, err := config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+
+	if len(config.Headers) == 0 {
+		return opts, nil
+	}
+
+	md, err := parseHeaders(config.Headers)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts,
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(withMetadata(ctx, md), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(withMetadata(ctx, md), desc, cc, method, opts...)
+		}),
+	), nil
+}
+
+func (config GrpcClientConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify, // #nosec G402 -- opt-in by flag
+	}
+
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in CA file %s", config.CAFile)
+		}
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		if config.CertFile == "" || config.KeyFile == "" {
+			return nil, fmt.Errorf("the client certificate needs both a cert and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// HeaderKeys lists the metadata keys without their values, which may be secrets.
+func (config GrpcClientConfig) HeaderKeys() []string {
+	keys := make([]string, 0, len(config.Headers))
+	for _, header := range config.Headers {
+		key, _, _ := strings.Cut(header, "=")
+		keys = append(keys, strings.TrimSpace(key))
+	}
+	return keys
+}
+
+func parseHeaders(headers []string) (metadata.MD, error) {
+	md := metadata.MD{}
+	for _, header := range headers {
+		key, value, found := strings.Cut(header, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("header %q is not in the key=value form", header)
+		}
+		md.Append(key, strings.TrimSpace(value))
+	}
+	return md, nil
+}
+
+func withMetadata(ctx context.Context, md metadata.MD) context.Context {
+	if outgoing, ok := metadata.FromOutgoingContext(ctx); ok {
+		return metadata.NewOutgoingContext(ctx, metadata.Join(outgoing, md))
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
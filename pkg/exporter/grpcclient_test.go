@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+
+	tmservice "github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+)
+
+// selfSignedCert writes a certificate for serverName to dir and returns it with its PEM path.
+func selfSignedCert(t *testing.T, dir, serverName string) (tls.Certificate, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: serverName},
+		DNSNames:              []string{serverName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	path := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(path, certPEM, 0o600))
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	require.NoError(t, err)
+
+	return cert, path
+}
+
+func TestGrpcClientConfigTLS(t *testing.T) {
+	cert, caFile := selfSignedCert(t, t.TempDir(), "node.example")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	received := make(chan metadata.MD, 1)
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewServerTLSFromCert(&cert)),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			select {
+			case received <- md:
+			default:
+			}
+			return handler(ctx, req)
+		}),
+	)
+	node := &fakeNode{}
+	node.height.Store(42)
+	tmservice.RegisterServiceServer(server, node)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	client := GrpcClientConfig{
+		CAFile:     caFile,
+		ServerName: "node.example",
+		Headers:    []string{"x-api-key=secret"},
+	}
+	s := &Service{Log: zerolog.Nop()}
+	pool, err := NewNodePool(s, []string{listener.Addr().String()}, client, 5, 0)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	height, err := s.GetLatestBlock(context.Background(), pool)
+	require.NoError(t, err)
+	require.InDelta(t, 42, height, 0)
+	require.Equal(t, []string{"secret"}, (<-received).Get("x-api-key"))
+
+	// without the CA the certificate is rejected
+	plain, err := NewNodePool(s, []string{listener.Addr().String()}, GrpcClientConfig{TLS: true}, 5, 0)
+	require.NoError(t, err)
+	defer plain.Close()
+	_, err = s.GetLatestBlock(context.Background(), plain)
+	require.Error(t, err)
+}
+
+func TestGrpcClientConfigErrors(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Config GrpcClientConfig
+	}{
+		{Name: "missing CA file", Config: GrpcClientConfig{CAFile: "/nonexistent/ca.pem"}},
+		{Name: "cert without key", Config: GrpcClientConfig{CertFile: "cert.pem"}},
+		{Name: "header without value", Config: GrpcClientConfig{Headers: []string{"x-api-key"}}},
+	}
+
+	for _, tt := range tests {
+		_, err := tt.Config.DialOptions("localhost:9090")
+		require.Error(t, err, tt.Name)
+	}
+
+	require.Equal(t, []string{"x-api-key", "authorization"},
+		GrpcClientConfig{Headers: []string{"x-api-key=a", "authorization=Bearer b"}}.HeaderKeys())
+}
@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	tmservice "github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+)
+
+// HealthCheck is the outcome of one of the checks behind /readyz.
+type HealthCheck struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	// Value is what the check looked at, e.g. the height or the age of the latest block
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Readiness is the body of /readyz for one chain.
+type Readiness struct {
+	Ready  bool                   `json:"ready"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+// Readiness checks that the node answers over gRPC, is not syncing, and that its latest
+// block is at most --ready-max-block-age old.
+func (s *Service) Readiness(ctx context.Context) Readiness {
+	if s.Config.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Config.QueryTimeout)
+		defer cancel()
+	}
+
+	var mu sync.Mutex
+	checks := make(map[string]HealthCheck, 3)
+	record := func(name string, check HealthCheck) {
+		mu.Lock()
+		defer mu.Unlock()
+		checks[name] = check
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		height, err := s.GetLatestBlock(ctx, s.GrpcConn)
+		if err != nil {
+			record("grpc", HealthCheck{Error: err.Error()})
+			return
+		}
+		record("grpc", HealthCheck{OK: true, Value: height})
+	}()
+	go func() {
+		defer wg.Done()
+		serviceClient := tmservice.NewServiceClient(s.GrpcConn)
+		response, err := serviceClient.GetSyncing(ctx, &tmservice.GetSyncingRequest{})
+		switch {
+		case err != nil:
+			record("syncing", HealthCheck{Error: err.Error()})
+		case response.Syncing:
+			record("syncing", HealthCheck{Error: "node is syncing", Value: true})
+		default:
+			record("syncing", HealthCheck{OK: true, Value: false})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		status, err := NewChainStatus(ctx, s.Config)
+		if err != nil {
+			record("block_age", HealthCheck{Error: err.Error()})
+			return
+		}
+		age := time.Since(status.LatestBlockTime())
+		if age > s.Config.ReadyMaxBlockAge {
+			record("block_age", HealthCheck{Error: "latest block is older than " + s.Config.ReadyMaxBlockAge.String(), Value: age.Seconds()})
+			return
+		}
+		record("block_age", HealthCheck{OK: true, Value: age.Seconds()})
+	}()
+	wg.Wait()
+
+	ready := true
+	for _, check := range checks {
+		ready = ready && check.OK
+	}
+	return Readiness{Ready: ready, Checks: checks}
+}
+
+func (s *Service) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	readiness := s.Readiness(r.Context())
+	if !readiness.Ready {
+		s.Log.Warn().Interface("checks", readiness.Checks).Msg("Not ready")
+	}
+	writeHealth(w, readiness.Ready, readiness)
+}
+
+// HealthzHandler answers as long as the process serves requests.
+func HealthzHandler(w http.ResponseWriter, _ *http.Request) {
+	writeHealth(w, true, map[string]string{"status": "ok"})
+}
+
+// ReadyzHandler is ready when every chain is, the body holds the checks of each chain.
+func (c *Chains) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	chains := make(map[string]Readiness, len(c.services))
+	for name, s := range c.services {
+		wg.Add(1)
+		go func(name string, s *Service) {
+			defer wg.Done()
+			readiness := s.Readiness(r.Context())
+			if !readiness.Ready {
+				s.Log.Warn().Interface("checks", readiness.Checks).Msg("Not ready")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			chains[name] = readiness
+		}(name, s)
+	}
+	wg.Wait()
+
+	ready := true
+	for _, readiness := range chains {
+		ready = ready && readiness.Ready
+	}
+	writeHealth(w, ready, struct {
+		Ready  bool                 `json:"ready"`
+		Chains map[string]Readiness `json:"chains"`
+	}{Ready: ready, Chains: chains})
+}
+
+func writeHealth(w http.ResponseWriter, ok bool, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,82 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// startFakeTendermint answers the status JSON-RPC call with a latest block of the given time,
+// the node holding the 100 blocks before it, made every 6 seconds.
+func startFakeTendermint(t *testing.T, latestBlockTime time.Time) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID json.RawMessage `json:"id"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"sync_info":{"latest_block_height":"100","latest_block_time":%q,"earliest_block_height":"1","earliest_block_time":%q}}}`,
+			request.ID, latestBlockTime.Format(time.RFC3339Nano), latestBlockTime.Add(-99*6*time.Second).Format(time.RFC3339Nano))
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func TestReadyz(t *testing.T) {
+	node, address := startFakeNode(t, 100)
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	s := &Service{
+		Log:      zerolog.Nop(),
+		GrpcConn: conn,
+		Config: &ServiceConfig{
+			TendermintRPC:    startFakeTendermint(t, time.Now().Add(-10*time.Second)),
+			ReadyMaxBlockAge: time.Minute,
+			QueryTimeout:     5 * time.Second,
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	s.ReadyzHandler(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var readiness Readiness
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &readiness))
+	require.True(t, readiness.Ready)
+	require.Len(t, readiness.Checks, 3)
+
+	node.syncing.Store(true)
+	s.Config.TendermintRPC = startFakeTendermint(t, time.Now().Add(-time.Hour))
+
+	recorder = httptest.NewRecorder()
+	s.ReadyzHandler(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &readiness))
+	require.False(t, readiness.Ready)
+	require.True(t, readiness.Checks["grpc"].OK)
+	require.False(t, readiness.Checks["syncing"].OK)
+	require.False(t, readiness.Checks["block_age"].OK)
+
+	node.down.Store(true)
+	readiness = s.Readiness(httptest.NewRequest(http.MethodGet, "/readyz", nil).Context())
+	require.False(t, readiness.Checks["grpc"].OK)
+}
+
+func TestHealthz(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	HealthzHandler(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+}
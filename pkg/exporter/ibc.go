@@ -0,0 +1,135 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ibctransfertypes "github.com/cosmos/ibc-go/v8/modules/apps/transfer/types"
+)
+
+const (
+	// denomTraceWorkers is how many traces ResolveDenomTraces queries at once.
+	denomTraceWorkers = 8
+	// denomTraceRetryDelay is how long a denom whose trace query failed is left untraced.
+	denomTraceRetryDelay = time.Minute
+)
+
+// DenomTraces resolves ibc/<hash> denoms to the denom they were sent as and the channels
+// they went through. A trace never changes for a hash, so they are cached for good. The
+// failed queries are remembered for denomTraceRetryDelay, so that a failing node does not
+// get asked for every trace on every scrape.
+type DenomTraces struct {
+	mu     sync.RWMutex
+	traces map[string]ibctransfertypes.DenomTrace
+	failed map[string]time.Time
+}
+
+func NewDenomTraces() *DenomTraces {
+	return &DenomTraces{
+		traces: make(map[string]ibctransfertypes.DenomTrace),
+		failed: make(map[string]time.Time),
+	}
+}
+
+func (t *DenomTraces) get(denom string) (ibctransfertypes.DenomTrace, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	trace, ok := t.traces[denom]
+	return trace, ok
+}
+
+func (t *DenomTraces) set(denom string, trace ibctransfertypes.DenomTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.traces[denom] = trace
+	delete(t.failed, denom)
+}
+
+func (t *DenomTraces) setFailed(denom string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failed[denom] = time.Now()
+}
+
+// pending tells whether the trace of denom is neither cached nor recently failed.
+func (t *DenomTraces) pending(denom string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if _, ok := t.traces[denom]; ok {
+		return false
+	}
+	failed, ok := t.failed[denom]
+	return !ok || time.Since(failed) >= denomTraceRetryDelay
+}
+
+// ResolveDenomTraces queries the traces of the IBC denoms that are not cached yet, a few at a
+// time, so that a list of coins is then labelled from the cache instead of one query per coin.
+func (s *Service) ResolveDenomTraces(ctx context.Context, denoms []string) {
+	if s.DenomTraces == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, denomTraceWorkers)
+	for _, denom := range denoms {
+		if !strings.HasPrefix(denom, "ibc/") || !s.DenomTraces.pending(denom) {
+			continue
+		}
+
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+			s.DenomTrace(ctx, denom)
+		}()
+	}
+	wg.Wait()
+}
+
+// DenomTrace returns the origin denom and the trace path of denom. Denoms that are not IBC
+// vouchers, or that the chain cannot trace, are their own origin with an empty path.
+func (s *Service) DenomTrace(ctx context.Context, denom string) (baseDenom string, path string) {
+	hash, isIBC := strings.CutPrefix(denom, "ibc/")
+	if !isIBC || s.DenomTraces == nil {
+		return denom, ""
+	}
+	if trace, ok := s.DenomTraces.get(denom); ok {
+		return trace.BaseDenom, trace.Path
+	}
+	if !s.DenomTraces.pending(denom) {
+		return denom, ""
+	}
+
+	transferClient := ibctransfertypes.NewQueryClient(s.GrpcConn)
+	response, err := transferClient.DenomTrace(
+		ctx,
+		&ibctransfertypes.QueryDenomTraceRequest{Hash: hash},
+	)
+	if err != nil {
+		s.Log.Debug().
+			Str("denom", denom).
+			Err(err).
+			Msg("Could not get denom trace")
+
+		// not worth asking again, other errors may be transient
+		if code := status.Code(err); code == codes.NotFound || code == codes.Unimplemented {
+			s.DenomTraces.set(denom, ibctransfertypes.DenomTrace{BaseDenom: denom})
+		} else {
+			s.DenomTraces.setFailed(denom)
+		}
+		return denom, ""
+	}
+
+	trace := response.GetDenomTrace()
+	if trace == nil || trace.BaseDenom == "" {
+		return denom, ""
+	}
+	s.DenomTraces.set(denom, *trace)
+	return trace.BaseDenom, trace.Path
+}
@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"cosmossdk.io/math"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	ibctransfertypes "github.com/cosmos/ibc-go/v8/modules/apps/transfer/types"
+)
+
+// fakeTransfer traces the ibc denoms of a fake chain. The hash UNAVAILABLE always fails.
+type fakeTransfer struct {
+	ibctransfertypes.UnimplementedQueryServer
+
+	traces map[string]ibctransfertypes.DenomTrace
+	calls  atomic.Int32
+}
+
+func (f *fakeTransfer) DenomTrace(_ context.Context, req *ibctransfertypes.QueryDenomTraceRequest) (*ibctransfertypes.QueryDenomTraceResponse, error) {
+	f.calls.Add(1)
+	if req.Hash == "UNAVAILABLE" {
+		return nil, status.Error(codes.Unavailable, "node is syncing")
+	}
+	trace, ok := f.traces[req.Hash]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "denomination trace not found")
+	}
+	return &ibctransfertypes.QueryDenomTraceResponse{DenomTrace: &trace}, nil
+}
+
+// fakeWalletBank serves the balances of every address.
+type fakeWalletBank struct {
+	banktypes.UnimplementedQueryServer
+
+	balances sdk.Coins
+}
+
+func (f *fakeWalletBank) AllBalances(_ context.Context, _ *banktypes.QueryAllBalancesRequest) (*banktypes.QueryAllBalancesResponse, error) {
+	return &banktypes.QueryAllBalancesResponse{Balances: f.balances}, nil
+}
+
+func TestDenomTrace(t *testing.T) {
+	transfer := &fakeTransfer{traces: map[string]ibctransfertypes.DenomTrace{
+		"27394FB0": {Path: "transfer/channel-0", BaseDenom: "uatom"},
+	}}
+	bank := &fakeWalletBank{balances: sdk.NewCoins(
+		sdk.NewCoin("ibc/27394FB0", math.NewInt(2_000_000)),
+		sdk.NewCoin("ustake", math.NewInt(3)),
+	)}
+	s := startFakeChain(t, func(server *grpc.Server) {
+		ibctransfertypes.RegisterQueryServer(server, transfer)
+		banktypes.RegisterQueryServer(server, bank)
+	})
+	s.Denoms = NewDenomRegistry()
+	s.Denoms.Set(DenomUnit{Base: "ibc/27394FB0", Display: "atom", Coefficient: 1e6})
+	s.DenomTraces = NewDenomTraces()
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		baseDenom, path := s.DenomTrace(ctx, "ibc/27394FB0")
+		require.Equal(t, "uatom", baseDenom)
+		require.Equal(t, "transfer/channel-0", path)
+	}
+	require.Equal(t, int32(1), transfer.calls.Load())
+
+	// unknown hashes are asked for once, other denoms never
+	for i := 0; i < 2; i++ {
+		baseDenom, path := s.DenomTrace(ctx, "ibc/UNKNOWN")
+		require.Equal(t, "ibc/UNKNOWN", baseDenom)
+		require.Empty(t, path)
+		baseDenom, _ = s.DenomTrace(ctx, "ustake")
+		require.Equal(t, "ustake", baseDenom)
+	}
+	require.Equal(t, int32(2), transfer.calls.Load())
+
+	registry := prometheus.NewRegistry()
+	var wg sync.WaitGroup
+	sublogger := s.Log
+	GetWalletMetrics(ctx, &wg, &sublogger, NewWalletMetrics(registry, s.Config), s, s.Config, nil, sdk.AccAddress("wallet"), true)
+	wg.Wait()
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP cosmos_wallet_balance Balance of the Cosmos-based blockchain wallet
+# TYPE cosmos_wallet_balance gauge
+cosmos_wallet_balance{address="cosmos1waskcmr9wsd67jn2",base_denom="uatom",denom="ibc/27394FB0",display_denom="atom",trace_path="transfer/channel-0"} 2
+cosmos_wallet_balance{address="cosmos1waskcmr9wsd67jn2",base_denom="ustake",denom="ustake",display_denom="ustake",trace_path=""} 3
+`), "cosmos_wallet_balance"))
+}
+
+func TestResolveDenomTraces(t *testing.T) {
+	transfer := &fakeTransfer{traces: map[string]ibctransfertypes.DenomTrace{}}
+	var denoms []string
+	for i := 0; i < 3*denomTraceWorkers; i++ {
+		hash := fmt.Sprintf("%08X", i)
+		transfer.traces[hash] = ibctransfertypes.DenomTrace{Path: "transfer/channel-0", BaseDenom: fmt.Sprintf("u%d", i)}
+		denoms = append(denoms, "ibc/"+hash)
+	}
+	denoms = append(denoms, "ibc/UNAVAILABLE", "ustake")
+	s := startFakeChain(t, func(server *grpc.Server) {
+		ibctransfertypes.RegisterQueryServer(server, transfer)
+	})
+	s.DenomTraces = NewDenomTraces()
+
+	ctx := context.Background()
+	s.ResolveDenomTraces(ctx, denoms)
+	require.Equal(t, int32(3*denomTraceWorkers+1), transfer.calls.Load())
+
+	// the resolved traces are served from the cache, the failed one is not asked again yet
+	s.ResolveDenomTraces(ctx, denoms)
+	baseDenom, path := s.DenomTrace(ctx, "ibc/00000001")
+	require.Equal(t, "u1", baseDenom)
+	require.Equal(t, "transfer/channel-0", path)
+	baseDenom, path = s.DenomTrace(ctx, "ibc/UNAVAILABLE")
+	require.Equal(t, "ibc/UNAVAILABLE", baseDenom)
+	require.Empty(t, path)
+	require.Equal(t, int32(3*denomTraceWorkers+1), transfer.calls.Load())
+
+	// it is after the retry delay
+	s.DenomTraces.failed["ibc/UNAVAILABLE"] = time.Now().Add(-denomTraceRetryDelay)
+	s.ResolveDenomTraces(ctx, denoms)
+	require.Equal(t, int32(3*denomTraceWorkers+2), transfer.calls.Load())
+}
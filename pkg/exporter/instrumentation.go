@@ -0,0 +1,245 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExporterMetrics describe the exporter itself rather than the chain. They live for the
+// whole process and are served next to the per-request registry of every handler.
+type ExporterMetrics struct {
+	registry *prometheus.Registry
+
+	queryDuration *prometheus.HistogramVec
+	queryErrors   *prometheus.CounterVec
+	queryTimeouts *prometheus.CounterVec
+	collectorUp   *prometheus.GaugeVec
+	capped        *prometheus.CounterVec
+
+	configReloadSuccess   prometheus.Gauge
+	configReloadTimestamp prometheus.Gauge
+}
+
+func NewExporterMetrics(config *ServiceConfig) *ExporterMetrics {
+	m := &ExporterMetrics{
+		registry: prometheus.NewRegistry(),
+		queryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:        "cosmos_exporter_query_duration_seconds",
+				Help:        "Duration of the upstream queries made by the exporter",
+				ConstLabels: config.ConstLabels,
+				Buckets:     prometheus.DefBuckets,
+			},
+			[]string{"query"},
+		),
+		queryErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_exporter_query_errors_total",
+				Help:        "Number of failed upstream queries made by the exporter",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"query"},
+		),
+		queryTimeouts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_exporter_query_timeouts_total",
+				Help:        "Number of upstream queries that ran out of time",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"query"},
+		),
+		collectorUp: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_exporter_collector_up",
+				Help:        "1 if every query of the collector succeeded on its last run, 0 if no",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"collector"},
+		),
+		capped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_exporter_pagination_capped_total",
+				Help:        "Number of list queries stopped after --max-pages pages, their metrics are partial",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"query"},
+		),
+		configReloadSuccess: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_exporter_config_reload_success",
+				Help:        "1 if the last reload of the config file succeeded, 0 if no",
+				ConstLabels: config.ConstLabels,
+			},
+		),
+		configReloadTimestamp: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_exporter_config_last_reload_success_timestamp_seconds",
+				Help:        "Timestamp of the last successful load of the config, the start of the process if never reloaded",
+				ConstLabels: config.ConstLabels,
+			},
+		),
+	}
+	// the config was loaded on startup
+	m.configReloadSuccess.Set(1)
+	m.configReloadTimestamp.SetToCurrentTime()
+
+	m.registry.MustRegister(m.queryDuration)
+	m.registry.MustRegister(m.queryErrors)
+	m.registry.MustRegister(m.queryTimeouts)
+	m.registry.MustRegister(m.collectorUp)
+	m.registry.MustRegister(m.capped)
+	m.registry.MustRegister(m.configReloadSuccess)
+	m.registry.MustRegister(m.configReloadTimestamp)
+
+	return m
+}
+
+// CollectorRun tracks whether any query failed during a single run of a collector.
+type CollectorRun struct {
+	name   string
+	failed atomic.Bool
+}
+
+type collectorRunKey struct{}
+
+// StartCollector returns a context that attributes failed queries to the named collector.
+func (s *Service) StartCollector(ctx context.Context, name string) (context.Context, *CollectorRun) {
+	run := &CollectorRun{name: name}
+	return context.WithValue(ctx, collectorRunKey{}, run), run
+}
+
+// FinishCollector publishes the outcome of a collector run. Must be called after its queries are done.
+func (s *Service) FinishCollector(run *CollectorRun) {
+	if s.Metrics == nil {
+		return
+	}
+
+	up := float64(1)
+	if run.failed.Load() {
+		up = 0
+	}
+	s.Metrics.collectorUp.WithLabelValues(run.name).Set(up)
+}
+
+// ObservePaginationCapped records a list query that had more than --max-pages pages.
+func (s *Service) ObservePaginationCapped(query string, items int) {
+	s.Log.Warn().
+		Str("query", query).
+		Int("items", items).
+		Int("max-pages", s.Config.MaxPages).
+		Msg("Stopped paginating, the result is partial")
+
+	if s.Metrics == nil {
+		return
+	}
+	s.Metrics.capped.WithLabelValues(query).Inc()
+}
+
+// ObserveReload publishes the outcome of a reload of the config file.
+func (s *Service) ObserveReload(err error) {
+	if s.Metrics == nil {
+		return
+	}
+
+	if err != nil {
+		s.Metrics.configReloadSuccess.Set(0)
+		return
+	}
+	s.Metrics.configReloadSuccess.Set(1)
+	s.Metrics.configReloadTimestamp.SetToCurrentTime()
+}
+
+// ObserveQuery records the duration and outcome of an upstream query.
+// Queries made over the gRPC connections are recorded automatically by the interceptor.
+func (s *Service) ObserveQuery(ctx context.Context, query string, start time.Time, err error) {
+	if status.Code(err) == codes.NotFound {
+		// not found is an answer (e.g. no vote or no signing info yet), not a failure
+		err = nil
+	}
+
+	if err != nil {
+		if run, ok := ctx.Value(collectorRunKey{}).(*CollectorRun); ok {
+			run.failed.Store(true)
+		}
+	}
+
+	if s.Metrics == nil {
+		return
+	}
+
+	s.Metrics.queryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+	if err != nil {
+		s.Metrics.queryErrors.WithLabelValues(query).Inc()
+	}
+	if isTimeout(err) {
+		s.Metrics.queryTimeouts.WithLabelValues(query).Inc()
+		s.Log.Warn().
+			Str("query", query).
+			Float64("request-time", time.Since(start).Seconds()).
+			Msg("Query timed out")
+	}
+}
+
+func isTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
+
+func (s *Service) unaryInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if s.Config != nil && s.Config.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Config.QueryTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	s.ObserveQuery(ctx, queryName(method), start, err)
+	return err
+}
+
+// Gatherer combines a per-request registry with the exporter metrics.
+func (s *Service) Gatherer(registry *prometheus.Registry) prometheus.Gatherer {
+	if s.Metrics == nil {
+		return registry
+	}
+	return prometheus.Gatherers{registry, s.Metrics.registry}
+}
+
+// queryName shortens a full gRPC method name to module.Method,
+// e.g. /cosmos.staking.v1beta1.Query/Validator becomes staking.Validator.
+func queryName(method string) string {
+	service, rpc, found := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	if !found {
+		return method
+	}
+
+	parts := strings.Split(service, ".")
+	module := parts[0]
+	// walk back from the service name, skipping the version segments
+	for i := len(parts) - 2; i >= 0; i-- {
+		if !isVersion(parts[i]) {
+			module = parts[i]
+			break
+		}
+	}
+	return module + "." + rpc
+}
+
+func isVersion(part string) bool {
+	return len(part) > 1 && part[0] == 'v' && part[1] >= '0' && part[1] <= '9'
+}
@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		Method   string
+		Expected string
+	}{
+		{Method: "/cosmos.staking.v1beta1.Query/Validator", Expected: "staking.Validator"},
+		{Method: "/cosmos.slashing.v1beta1.Query/SigningInfo", Expected: "slashing.SigningInfo"},
+		{Method: "/cosmos.gov.v1.Query/Proposals", Expected: "gov.Proposals"},
+		{Method: "/cosmos.base.tendermint.v1beta1.Service/GetLatestBlock", Expected: "tendermint.GetLatestBlock"},
+		{Method: "/kujira.oracle.Query/MissCounter", Expected: "oracle.MissCounter"},
+		{Method: "cosmosdirectory", Expected: "cosmosdirectory"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Method, func(t *testing.T) {
+			require.Equal(t, test.Expected, queryName(test.Method))
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+
+	tmservice "github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+)
+
+// nodeEndpoint is one of the --node addresses with the outcome of its last health check.
+type nodeEndpoint struct {
+	address string
+	conn    *grpc.ClientConn
+
+	up      bool
+	syncing bool
+	height  int64
+	healthy bool
+}
+
+// NodePool sends every query to one active --node endpoint. It checks all endpoints
+// periodically and fails over when the active one is down, syncing or lagging behind.
+type NodePool struct {
+	s         *Service
+	endpoints []*nodeEndpoint
+	maxLag    int64
+	interval  time.Duration
+	cancel    context.CancelFunc
+
+	mu     sync.RWMutex
+	active *nodeEndpoint
+}
+
+// NewNodePool dials every address with the same client settings, checks them once to pick the active endpoint and
+// keeps checking them in the background, every interval.
+func NewNodePool(s *Service, addresses []string, client GrpcClientConfig, maxLag int64, interval time.Duration, opts ...grpc.DialOption) (*NodePool, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no node address")
+	}
+
+	p := &NodePool{
+		s:        s,
+		maxLag:   maxLag,
+		interval: interval,
+	}
+
+	for _, address := range addresses {
+		clientOpts, err := client.DialOptions(address)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("node %s: %w", address, err)
+		}
+
+		conn, err := grpc.Dial(address, append(clientOpts, opts...)...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.endpoints = append(p.endpoints, &nodeEndpoint{address: address, conn: conn})
+	}
+	p.active = p.endpoints[0]
+
+	// with a single endpoint there is nothing to fail over to
+	if len(p.endpoints) > 1 && interval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		p.cancel = cancel
+		p.check(ctx)
+		go p.run(ctx)
+	}
+
+	return p, nil
+}
+
+// Invoke implements grpc.ClientConnInterface on the active endpoint.
+func (p *NodePool) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	return p.Active().Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream implements grpc.ClientConnInterface on the active endpoint.
+func (p *NodePool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.Active().NewStream(ctx, desc, method, opts...)
+}
+
+// Active returns the connection of the endpoint queries are currently sent to.
+func (p *NodePool) Active() *grpc.ClientConn {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.active.conn
+}
+
+func (p *NodePool) Close() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+
+	var err error
+	for _, endpoint := range p.endpoints {
+		if closeErr := endpoint.conn.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (p *NodePool) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.check(ctx)
+		}
+	}
+}
+
+type nodeHealth struct {
+	up      bool
+	syncing bool
+	height  int64
+}
+
+// check queries every endpoint, then keeps the active endpoint while it is healthy,
+// otherwise switches to the healthy endpoint with the highest block.
+func (p *NodePool) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.interval)
+	defer cancel()
+
+	results := make([]nodeHealth, len(p.endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range p.endpoints {
+		wg.Add(1)
+		go func(i int, endpoint *nodeEndpoint) {
+			defer wg.Done()
+			results[i] = p.checkEndpoint(ctx, endpoint)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	var best int64
+	for _, result := range results {
+		if result.up && result.height > best {
+			best = result.height
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var candidate *nodeEndpoint
+	for i, endpoint := range p.endpoints {
+		endpoint.up = results[i].up
+		endpoint.syncing = results[i].syncing
+		endpoint.height = results[i].height
+		endpoint.healthy = endpoint.up && !endpoint.syncing && best-endpoint.height <= p.maxLag
+
+		if endpoint.healthy && (candidate == nil || endpoint.height > candidate.height) {
+			candidate = endpoint
+		}
+	}
+
+	if p.active.healthy || candidate == nil {
+		if candidate == nil {
+			p.s.Log.Warn().Str("endpoint", p.active.address).Msg("No healthy node endpoint, staying on the active one")
+		}
+		return
+	}
+
+	p.s.Log.Warn().
+		Str("from", p.active.address).
+		Str("to", candidate.address).
+		Bool("from-up", p.active.up).
+		Bool("from-syncing", p.active.syncing).
+		Int64("from-height", p.active.height).
+		Int64("to-height", candidate.height).
+		Msg("Switching to another node endpoint")
+	p.active = candidate
+}
+
+func (p *NodePool) checkEndpoint(ctx context.Context, endpoint *nodeEndpoint) nodeHealth {
+	serviceClient := tmservice.NewServiceClient(endpoint.conn)
+	syncing, err := serviceClient.GetSyncing(ctx, &tmservice.GetSyncingRequest{})
+	if err != nil {
+		p.s.Log.Debug().Str("endpoint", endpoint.address).Err(err).Msg("Node endpoint is down")
+		return nodeHealth{}
+	}
+
+	height, err := p.s.GetLatestBlock(ctx, endpoint.conn)
+	if err != nil {
+		p.s.Log.Debug().Str("endpoint", endpoint.address).Err(err).Msg("Could not get latest block of node endpoint")
+		return nodeHealth{}
+	}
+
+	return nodeHealth{up: true, syncing: syncing.Syncing, height: int64(height)}
+}
+
+// nodePoolCollector exposes the state of the endpoints as of their last health check.
+type nodePoolCollector struct {
+	pool *NodePool
+
+	active  *prometheus.Desc
+	up      *prometheus.Desc
+	syncing *prometheus.Desc
+	height  *prometheus.Desc
+	healthy *prometheus.Desc
+}
+
+func newNodePoolCollector(pool *NodePool, constLabels prometheus.Labels) *nodePoolCollector {
+	return &nodePoolCollector{
+		pool: pool,
+		active: prometheus.NewDesc(
+			"cosmos_exporter_active_endpoint",
+			"1 for the node endpoint the queries are sent to, 0 for the others",
+			[]string{"endpoint"}, constLabels,
+		),
+		up: prometheus.NewDesc(
+			"cosmos_exporter_endpoint_up",
+			"1 if the node endpoint answered its last health check, 0 if no",
+			[]string{"endpoint"}, constLabels,
+		),
+		syncing: prometheus.NewDesc(
+			"cosmos_exporter_endpoint_syncing",
+			"1 if the node endpoint was syncing on its last health check, 0 if no",
+			[]string{"endpoint"}, constLabels,
+		),
+		height: prometheus.NewDesc(
+			"cosmos_exporter_endpoint_block_height",
+			"Latest block of the node endpoint on its last health check",
+			[]string{"endpoint"}, constLabels,
+		),
+		healthy: prometheus.NewDesc(
+			"cosmos_exporter_endpoint_healthy",
+			"1 if the node endpoint can be failed over to, 0 if no",
+			[]string{"endpoint"}, constLabels,
+		),
+	}
+}
+
+func (c *nodePoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.active
+	ch <- c.up
+	ch <- c.syncing
+	ch <- c.height
+	ch <- c.healthy
+}
+
+func (c *nodePoolCollector) Collect(ch chan<- prometheus.Metric) {
+	c.pool.mu.RLock()
+	defer c.pool.mu.RUnlock()
+
+	for _, endpoint := range c.pool.endpoints {
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, boolToFloat64(endpoint == c.pool.active), endpoint.address)
+
+		// the health gauges are only meaningful once the endpoints are checked
+		if len(c.pool.endpoints) < 2 {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, boolToFloat64(endpoint.up), endpoint.address)
+		ch <- prometheus.MustNewConstMetric(c.syncing, prometheus.GaugeValue, boolToFloat64(endpoint.syncing), endpoint.address)
+		ch <- prometheus.MustNewConstMetric(c.height, prometheus.GaugeValue, float64(endpoint.height), endpoint.address)
+		ch <- prometheus.MustNewConstMetric(c.healthy, prometheus.GaugeValue, boolToFloat64(endpoint.healthy), endpoint.address)
+	}
+}
@@ -0,0 +1,97 @@
+package exporter
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	tmservice "github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+)
+
+type fakeNode struct {
+	tmservice.UnimplementedServiceServer
+
+	down    atomic.Bool
+	syncing atomic.Bool
+	height  atomic.Int64
+}
+
+func (n *fakeNode) GetSyncing(_ context.Context, _ *tmservice.GetSyncingRequest) (*tmservice.GetSyncingResponse, error) {
+	if n.down.Load() {
+		return nil, status.Error(codes.Unavailable, "down")
+	}
+	return &tmservice.GetSyncingResponse{Syncing: n.syncing.Load()}, nil
+}
+
+func (n *fakeNode) GetLatestBlock(_ context.Context, _ *tmservice.GetLatestBlockRequest) (*tmservice.GetLatestBlockResponse, error) {
+	if n.down.Load() {
+		return nil, status.Error(codes.Unavailable, "down")
+	}
+	return &tmservice.GetLatestBlockResponse{
+		SdkBlock: &tmservice.Block{Header: tmservice.Header{Height: n.height.Load()}},
+	}, nil
+}
+
+func startFakeNode(t *testing.T, height int64) (*fakeNode, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	node := &fakeNode{}
+	node.height.Store(height)
+
+	server := grpc.NewServer()
+	tmservice.RegisterServiceServer(server, node)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return node, listener.Addr().String()
+}
+
+func TestNodePoolFailover(t *testing.T) {
+	first, firstAddress := startFakeNode(t, 100)
+	second, secondAddress := startFakeNode(t, 100)
+
+	s := &Service{Log: zerolog.Nop()}
+	// a long interval, the checks are triggered by hand
+	pool, err := NewNodePool(s, []string{firstAddress, secondAddress}, GrpcClientConfig{}, 5, time.Hour)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	require.Equal(t, firstAddress, pool.Active().Target())
+
+	// the active node stays active while healthy, even if another one is ahead
+	second.height.Store(103)
+	pool.check(context.Background())
+	require.Equal(t, firstAddress, pool.Active().Target())
+
+	first.down.Store(true)
+	pool.check(context.Background())
+	require.Equal(t, secondAddress, pool.Active().Target())
+
+	// a node that is back but lagging behind is not failed back to
+	first.down.Store(false)
+	second.syncing.Store(true)
+	second.height.Store(200)
+	pool.check(context.Background())
+	require.Equal(t, secondAddress, pool.Active().Target(), "no healthy endpoint, stays on the active one")
+
+	second.syncing.Store(false)
+	second.down.Store(true)
+	pool.check(context.Background())
+	require.Equal(t, firstAddress, pool.Active().Target())
+
+	// queries go to the active endpoint
+	height, err := s.GetLatestBlock(context.Background(), pool)
+	require.NoError(t, err)
+	require.InDelta(t, 100, height, 0)
+}
@@ -0,0 +1,55 @@
+package exporter
+
+import (
+	"context"
+
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+)
+
+// PageQuery fetches one page of a list query, returning its items and pagination.
+type PageQuery[T any] func(ctx context.Context, page *querytypes.PageRequest) ([]T, *querytypes.PageResponse, error)
+
+// Paginate returns the items of every page of query, following Pagination.NextKey. The
+// first page is requested with --limit items. After --max-pages pages it stops, logs and
+// counts the capped query under name in cosmos_exporter_pagination_capped_total, and
+// returns what it got so far.
+func Paginate[T any](ctx context.Context, s *Service, name string, query PageQuery[T]) ([]T, error) {
+	var items []T
+	page := &querytypes.PageRequest{Limit: s.Config.Limit}
+
+	for pages := 1; ; pages++ {
+		pageItems, pagination, err := query(ctx, page)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, pageItems...)
+
+		if pagination == nil || len(pagination.NextKey) == 0 {
+			return items, nil
+		}
+		if s.Config.MaxPages > 0 && pages >= s.Config.MaxPages {
+			s.ObservePaginationCapped(name, len(items))
+			return items, nil
+		}
+		page = &querytypes.PageRequest{Key: pagination.NextKey, Limit: s.Config.Limit}
+	}
+}
+
+// CountAll returns the number of items of query. It asks the node to count them, and
+// paginates through them if the node does not.
+func CountAll[T any](ctx context.Context, s *Service, name string, query PageQuery[T]) (uint64, error) {
+	items, pagination, err := query(ctx, &querytypes.PageRequest{Limit: 1, CountTotal: true})
+	if err != nil {
+		return 0, err
+	}
+	if pagination == nil {
+		// not paginated, every item is in the response
+		return uint64(len(items)), nil
+	}
+	if pagination.Total > 0 || len(items) == 0 {
+		return pagination.Total, nil
+	}
+
+	all, err := Paginate(ctx, s, name, query)
+	return uint64(len(all)), err
+}
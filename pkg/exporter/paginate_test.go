@@ -0,0 +1,93 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+)
+
+// pagedList serves items page by page, the keys being the offsets of the pages.
+func pagedList(items []int, countTotal bool) PageQuery[int] {
+	return func(_ context.Context, page *querytypes.PageRequest) ([]int, *querytypes.PageResponse, error) {
+		start := 0
+		if len(page.Key) > 0 {
+			var err error
+			if start, err = strconv.Atoi(string(page.Key)); err != nil {
+				return nil, nil, err
+			}
+		}
+		end := start + int(page.Limit)
+		if end > len(items) {
+			end = len(items)
+		}
+
+		pagination := &querytypes.PageResponse{}
+		if end < len(items) {
+			pagination.NextKey = []byte(strconv.Itoa(end))
+		}
+		if countTotal && page.CountTotal {
+			pagination.Total = uint64(len(items))
+		}
+		return items[start:end], pagination, nil
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := make([]int, 25)
+	for i := range items {
+		items[i] = i
+	}
+
+	config := &ServiceConfig{Limit: 10, MaxPages: 5}
+	s := &Service{Log: zerolog.Nop(), Config: config}
+	s.Metrics = NewExporterMetrics(config)
+
+	all, err := Paginate(context.Background(), s, "test.List", pagedList(items, false))
+	require.NoError(t, err)
+	require.Equal(t, items, all)
+	require.InDelta(t, 0, testutil.ToFloat64(s.Metrics.capped.WithLabelValues("test.List")), 0)
+
+	config.MaxPages = 2
+	capped, err := Paginate(context.Background(), s, "test.List", pagedList(items, false))
+	require.NoError(t, err)
+	require.Equal(t, items[:20], capped)
+	require.InDelta(t, 1, testutil.ToFloat64(s.Metrics.capped.WithLabelValues("test.List")), 0)
+
+	failing := func(_ context.Context, _ *querytypes.PageRequest) ([]int, *querytypes.PageResponse, error) {
+		return nil, nil, errors.New("unavailable")
+	}
+	_, err = Paginate(context.Background(), s, "test.List", failing)
+	require.Error(t, err)
+}
+
+func TestCountAll(t *testing.T) {
+	items := make([]int, 25)
+	s := &Service{Log: zerolog.Nop(), Config: &ServiceConfig{Limit: 10}}
+
+	counted, err := CountAll(context.Background(), s, "test.List", pagedList(items, true))
+	require.NoError(t, err)
+	require.Equal(t, uint64(25), counted)
+
+	// nodes not counting the items are paginated through
+	paginated, err := CountAll(context.Background(), s, "test.List", pagedList(items, false))
+	require.NoError(t, err)
+	require.Equal(t, uint64(25), paginated)
+
+	empty, err := CountAll(context.Background(), s, "test.List", pagedList(nil, false))
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), empty)
+
+	unpaginated := func(_ context.Context, _ *querytypes.PageRequest) ([]int, *querytypes.PageResponse, error) {
+		return items, nil, nil
+	}
+	listed, err := CountAll(context.Background(), s, "test.List", unpaginated)
+	require.NoError(t, err)
+	require.Equal(t, uint64(25), listed)
+}
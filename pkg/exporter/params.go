@@ -167,7 +167,7 @@ func NewParamsMetrics(reg prometheus.Registerer, config *ServiceConfig) *ParamsM
 	return m
 }
 
-func GetParamsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ParamsMetrics, s *Service, config *ServiceConfig) {
+func GetParamsMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ParamsMetrics, s *Service, config *ServiceConfig) {
 	go func() {
 		defer wg.Done()
 		sublogger.Debug().Msg("Started querying global staking params")
@@ -175,7 +175,7 @@ func GetParamsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *Pa
 
 		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
 		paramsResponse, err := stakingClient.Params(
-			context.Background(),
+			ctx,
 			&stakingtypes.QueryParamsRequest{},
 		)
 		if err != nil {
@@ -202,7 +202,7 @@ func GetParamsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *Pa
 
 			mintClient := minttypes.NewQueryClient(s.GrpcConn)
 			paramsResponse, err := mintClient.Params(
-				context.Background(),
+				ctx,
 				&minttypes.QueryParamsRequest{},
 			)
 			if err != nil {
@@ -261,7 +261,7 @@ func GetParamsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *Pa
 
 		slashingClient := slashingtypes.NewQueryClient(s.GrpcConn)
 		paramsResponse, err := slashingClient.Params(
-			context.Background(),
+			ctx,
 			&slashingtypes.QueryParamsRequest{},
 		)
 		if err != nil {
@@ -311,7 +311,7 @@ func GetParamsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *Pa
 
 		distributionClient := distributiontypes.NewQueryClient(s.GrpcConn)
 		paramsResponse, err := distributionClient.Params(
-			context.Background(),
+			ctx,
 			&distributiontypes.QueryParamsRequest{},
 		)
 		if err != nil {
@@ -363,12 +363,17 @@ func (s *Service) ParamsHandler(w http.ResponseWriter, r *http.Request) {
 	registry := prometheus.NewRegistry()
 	paramsMetrics := NewParamsMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "params")
 	var wg sync.WaitGroup
-	GetParamsMetrics(&wg, &sublogger, paramsMetrics, s, s.Config)
+	GetParamsMetrics(ctx, &wg, &sublogger, paramsMetrics, s, s.Config)
 
 	wg.Wait()
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	s.FinishCollector(run)
+
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
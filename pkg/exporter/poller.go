@@ -0,0 +1,222 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// snapshot is the result of the last collection of a single metric group.
+type snapshot struct {
+	families []*dto.MetricFamily
+	taken    time.Time
+}
+
+// Poller refreshes every enabled single mode group in the background, each on its own
+// interval, and serves the last snapshots on /metrics instead of querying the node per scrape.
+type Poller struct {
+	s       *Service
+	started time.Time
+
+	mu        sync.RWMutex
+	snapshots map[string]snapshot
+	// enabled follows the toggles as of the last refresh of every group
+	enabled map[string]bool
+
+	registry *prometheus.Registry
+}
+
+func NewPoller(s *Service) *Poller {
+	p := &Poller{
+		s:         s,
+		started:   time.Now(),
+		snapshots: make(map[string]snapshot),
+		enabled:   make(map[string]bool),
+		registry:  prometheus.NewRegistry(),
+	}
+
+	for _, group := range singleGroups {
+		p.enabled[group.name] = group.enabled(s)
+	}
+	p.registry.MustRegister(&snapshotAgeCollector{
+		poller: p,
+		desc: prometheus.NewDesc(
+			"cosmos_exporter_snapshot_age_seconds",
+			"Seconds since the metric group was last refreshed",
+			[]string{"group"}, s.Config.ConstLabels,
+		),
+	})
+
+	return p
+}
+
+// Start launches one refresh loop per group, the groups disabled by the toggles are skipped
+// until a reload enables them. The loops stop when ctx is cancelled.
+func (p *Poller) Start(ctx context.Context) {
+	for _, group := range singleGroups {
+		interval := p.s.Config.PollInterval(group.name)
+		p.s.Log.Info().
+			Str("group", group.name).
+			Bool("enabled", p.isEnabled(group.name)).
+			Dur("interval", interval).
+			Msg("Starting background polling")
+
+		go p.run(ctx, group, interval)
+	}
+}
+
+func (p *Poller) run(ctx context.Context, group singleGroup, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.refresh(ctx, group, interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh collects the group once. A run may not take longer than the group interval. It
+// works on a snapshot of the toggles, a reload during the run applies to the next one.
+func (p *Poller) refresh(ctx context.Context, group singleGroup, interval time.Duration) {
+	s := p.s.snapshot()
+	if !p.setEnabled(group.name, group.enabled(s)) {
+		return
+	}
+
+	refreshStart := time.Now()
+
+	sublogger := p.s.Log.With().
+		Str("request-id", uuid.New().String()).
+		Str("group", group.name).
+		Logger()
+
+	registry := prometheus.NewRegistry()
+	ctx, cancel := context.WithTimeout(ctx, interval)
+	defer cancel()
+	ctx, run := p.s.StartCollector(ctx, group.name)
+
+	var prices map[string]float64
+	if group.prices {
+		prices = s.PricesUSD(ctx, &sublogger)
+	}
+
+	var wg sync.WaitGroup
+	group.collect(s, ctx, &wg, &sublogger, registry, prices)
+	wg.Wait()
+	p.s.FinishCollector(run)
+
+	if run.failed.Load() && p.hasSnapshot(group.name) {
+		sublogger.Warn().Msg("Some queries of the metric group failed, keeping previous snapshot")
+		return
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		sublogger.Error().
+			Err(err).
+			Msg("Could not gather metric group, keeping previous snapshot")
+		return
+	}
+
+	p.mu.Lock()
+	p.snapshots[group.name] = snapshot{families: families, taken: time.Now()}
+	p.mu.Unlock()
+
+	sublogger.Debug().
+		Float64("request-time", time.Since(refreshStart).Seconds()).
+		Msg("Refreshed metric group")
+}
+
+// setEnabled records whether the group is enabled, dropping its snapshot if not.
+func (p *Poller) setEnabled(group string, enabled bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.enabled[group] = enabled
+	if !enabled {
+		delete(p.snapshots, group)
+	}
+	return enabled
+}
+
+func (p *Poller) isEnabled(group string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.enabled[group]
+}
+
+// snapshotTime returns when the group was last refreshed, or the poller start time if never.
+func (p *Poller) snapshotTime(group string) time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if snap, ok := p.snapshots[group]; ok {
+		return snap.taken
+	}
+	return p.started
+}
+
+func (p *Poller) hasSnapshot(group string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	_, ok := p.snapshots[group]
+	return ok
+}
+
+// Gather implements prometheus.Gatherer over the stored snapshots.
+func (p *Poller) Gather() ([]*dto.MetricFamily, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var families []*dto.MetricFamily
+	for _, snap := range p.snapshots {
+		families = append(families, snap.families...)
+	}
+	return families, nil
+}
+
+func (p *Poller) Handler(w http.ResponseWriter, r *http.Request) {
+	requestStart := time.Now()
+
+	h := promhttp.HandlerFor(prometheus.Gatherers{p, p.registry, p.s.Metrics.registry}, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	p.s.Log.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics").
+		Str("type", "snapshot").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
+
+// snapshotAgeCollector reports the age of the snapshot of every enabled group.
+type snapshotAgeCollector struct {
+	poller *Poller
+	desc   *prometheus.Desc
+}
+
+func (c *snapshotAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *snapshotAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, group := range singleGroups {
+		if !c.poller.isEnabled(group.name) {
+			continue
+		}
+		age := time.Since(c.poller.snapshotTime(group.name)).Seconds()
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, age, group.name)
+	}
+}
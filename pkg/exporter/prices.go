@@ -0,0 +1,312 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+
+	"github.com/pfc-developer/cosmos-exporter/pkg/cosmosdirectory"
+)
+
+// PriceProvider returns the USD price of one display unit of the coins it knows, keyed by
+// their base denom on the chain.
+type PriceProvider interface {
+	// Name is the value used in --price-providers.
+	Name() string
+	// Prices returns every price the provider knows.
+	Prices(ctx context.Context) (map[string]float64, error)
+}
+
+// CosmosDirectoryPrices takes the prices of the chain and of its assets from cosmos.directory.
+type CosmosDirectoryPrices struct {
+	Client  *cosmosdirectory.Client
+	ChainID string
+}
+
+func (p *CosmosDirectoryPrices) Name() string { return "cosmosdirectory" }
+
+func (p *CosmosDirectoryPrices) Prices(ctx context.Context) (map[string]float64, error) {
+	chain, err := p.Client.ChainByChainID(ctx, p.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64)
+	for _, asset := range chain.Assets {
+		if price := asset.GetPriceUSD(); price > 0 {
+			prices[asset.Base.Denom] = price
+		}
+	}
+	if price := chain.GetPriceUSD(); price > 0 {
+		prices[chain.Denom] = price
+	}
+	return prices, nil
+}
+
+// CoinGeckoPrices asks the simple/price endpoint of a CoinGecko compatible API for the coins
+// of IDs, which maps base denoms to CoinGecko ids.
+type CoinGeckoPrices struct {
+	// BaseURL is the API root, e.g. https://api.coingecko.com/api/v3
+	BaseURL    string
+	IDs        map[string]string
+	HTTPClient *http.Client
+}
+
+func (p *CoinGeckoPrices) Name() string { return "coingecko" }
+
+func (p *CoinGeckoPrices) Prices(ctx context.Context) (map[string]float64, error) {
+	if len(p.IDs) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	// several denoms may share an id, e.g. an IBC voucher and the native coin
+	unique := make(map[string]struct{}, len(p.IDs))
+	ids := make([]string, 0, len(p.IDs))
+	for _, id := range p.IDs {
+		if _, ok := unique[id]; !ok {
+			unique[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	query := url.Values{}
+	query.Set("ids", strings.Join(ids, ","))
+	query.Set("vs_currencies", "usd")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.BaseURL, "/")+"/simple/price?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coingecko answered %s", response.Status)
+	}
+
+	var body map[string]map[string]float64
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(p.IDs))
+	for denom, id := range p.IDs {
+		if price, ok := body[id]["usd"]; ok {
+			prices[denom] = price
+		}
+	}
+	return prices, nil
+}
+
+// FilePrices reads the prices from a YAML or JSON file mapping base denoms to USD prices.
+// The file is read on every call, so it can be updated while the exporter runs.
+type FilePrices struct {
+	Path string
+}
+
+func (p *FilePrices) Name() string { return "file" }
+
+func (p *FilePrices) Prices(_ context.Context) (map[string]float64, error) {
+	content, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML
+	prices := make(map[string]float64)
+	if err := yaml.Unmarshal(content, &prices); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", p.Path, err)
+	}
+	return prices, nil
+}
+
+// priceErrorTTL is how long a failing provider is left alone before it is asked again.
+const priceErrorTTL = 30 * time.Second
+
+// CachedPrices asks Provider for the prices at most once per TTL. When the provider fails,
+// the last prices it returned keep being served along with the error, and it is not asked
+// again for ErrorTTL, so that the collectors do not wait for it one after the other.
+type CachedPrices struct {
+	Provider PriceProvider
+	TTL      time.Duration
+	ErrorTTL time.Duration
+
+	mu      sync.Mutex
+	prices  map[string]float64
+	fetched time.Time
+	err     error
+	failed  time.Time
+}
+
+func (p *CachedPrices) Name() string { return p.Provider.Name() }
+
+func (p *CachedPrices) Prices(ctx context.Context) (map[string]float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.prices != nil && time.Since(p.fetched) < p.TTL {
+		return p.prices, nil
+	}
+	if p.err != nil && time.Since(p.failed) < p.ErrorTTL {
+		return p.prices, p.err
+	}
+
+	prices, err := p.Provider.Prices(ctx)
+	if err != nil {
+		p.err, p.failed = err, time.Now()
+		return p.prices, err
+	}
+	p.prices = prices
+	p.fetched = time.Now()
+	p.err = nil
+	return prices, nil
+}
+
+// FallbackPrices merges the prices of its providers: the price of a coin comes from the
+// first provider knowing it. It only fails when every provider does.
+type FallbackPrices []PriceProvider
+
+func (p FallbackPrices) Name() string {
+	names := make([]string, 0, len(p))
+	for _, provider := range p {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func (p FallbackPrices) Prices(ctx context.Context) (map[string]float64, error) {
+	merged := make(map[string]float64)
+	var errs []error
+	for _, provider := range p {
+		prices, err := provider.Prices(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		}
+		for denom, price := range prices {
+			if _, ok := merged[denom]; !ok {
+				merged[denom] = price
+			}
+		}
+	}
+	if len(errs) == len(p) && len(errs) > 0 {
+		return merged, errors.Join(errs...)
+	}
+	return merged, nil
+}
+
+// cosmosDirectories holds one client per URL, so the chains share the cached chain list.
+var cosmosDirectories sync.Map
+
+func cosmosDirectoryClient(url string) *cosmosdirectory.Client {
+	if url == "" {
+		url = cosmosdirectory.CosmosDirectoryURL
+	}
+	client, _ := cosmosDirectories.LoadOrStore(url, cosmosdirectory.NewClient(url))
+	return client.(*cosmosdirectory.Client)
+}
+
+// observedPrices records the calls to a provider like the other upstream queries, under the
+// name of the provider.
+type observedPrices struct {
+	PriceProvider
+	s *Service
+}
+
+func (p observedPrices) Prices(ctx context.Context) (map[string]float64, error) {
+	queryStart := time.Now()
+	prices, err := p.PriceProvider.Prices(ctx)
+	p.s.ObserveQuery(ctx, p.Name(), queryStart, err)
+	return prices, err
+}
+
+// NewPriceProvider builds the --price-providers chain, each provider being cached for
+// --price-cache-ttl.
+func (s *Service) NewPriceProvider(config *ServiceConfig) (PriceProvider, error) {
+	ids := make(map[string]string, len(config.CoinGeckoIDs))
+	for _, entry := range config.CoinGeckoIDs {
+		denom, id, found := strings.Cut(entry, "=")
+		if !found || denom == "" || id == "" {
+			return nil, fmt.Errorf("invalid --coingecko-ids entry %q, expected <base denom>=<coingecko id>", entry)
+		}
+		ids[denom] = id
+	}
+
+	var providers FallbackPrices
+	for _, name := range config.PriceProviders {
+		var provider PriceProvider
+		switch strings.TrimSpace(name) {
+		case "cosmosdirectory":
+			provider = &CosmosDirectoryPrices{Client: cosmosDirectoryClient(config.CosmosDirectoryURL), ChainID: config.ChainID}
+		case "coingecko":
+			provider = &CoinGeckoPrices{
+				BaseURL:    config.CoinGeckoURL,
+				IDs:        ids,
+				HTTPClient: &http.Client{Timeout: 5 * time.Second},
+			}
+		case "file":
+			if config.PriceFile == "" {
+				return nil, errors.New("the file price provider needs --price-file")
+			}
+			provider = &FilePrices{Path: config.PriceFile}
+		default:
+			return nil, fmt.Errorf("unknown price provider %q, available: cosmosdirectory, coingecko, file", name)
+		}
+		providers = append(providers, &CachedPrices{
+			Provider: observedPrices{PriceProvider: provider, s: s},
+			TTL:      config.PriceCacheTTL,
+			ErrorTTL: priceErrorTTL,
+		})
+	}
+	return providers, nil
+}
+
+// SetPrices configures the price providers of the service.
+func (s *Service) SetPrices(config *ServiceConfig) {
+	provider, err := s.NewPriceProvider(config)
+	if err != nil {
+		s.Log.Fatal().Err(err).Msg("Could not configure the price providers")
+	}
+	s.PriceProvider = provider
+}
+
+// PricesUSD returns the known USD prices, keyed by base denom. It returns nothing when
+// --price is off or no provider answers.
+func (s *Service) PricesUSD(ctx context.Context, sublogger *zerolog.Logger) map[string]float64 {
+	if !s.Config.TokenPrice || s.PriceProvider == nil {
+		return nil
+	}
+
+	prices, err := s.PriceProvider.Prices(ctx)
+	if err != nil {
+		sublogger.Error().Err(err).Msg("Could not get prices")
+	}
+	return prices
+}
+
+// setUSD sets the gauge to value, in display units of denom, times the price of denom when
+// there is one.
+func setUSD(gauge *prometheus.GaugeVec, labels prometheus.Labels, prices map[string]float64, denom string, value float64) {
+	if price, ok := prices[denom]; ok {
+		gauge.With(labels).Set(value * price)
+	}
+}
@@ -0,0 +1,204 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"cosmossdk.io/math"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+
+	"github.com/pfc-developer/cosmos-exporter/pkg/cosmosdirectory"
+)
+
+// fakePrices returns prices, or err, and counts its calls.
+type fakePrices struct {
+	name   string
+	prices map[string]float64
+	err    error
+	calls  atomic.Int32
+}
+
+func (f *fakePrices) Name() string { return f.name }
+
+func (f *fakePrices) Prices(_ context.Context) (map[string]float64, error) {
+	f.calls.Add(1)
+	return f.prices, f.err
+}
+
+func TestCoinGeckoPrices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v3/simple/price", r.URL.Path)
+		require.Equal(t, "cosmos,osmosis", r.URL.Query().Get("ids"))
+		require.Equal(t, "usd", r.URL.Query().Get("vs_currencies"))
+		_, _ = w.Write([]byte(`{"cosmos":{"usd":7.5},"osmosis":{"usd":0.5}}`))
+	}))
+	defer server.Close()
+
+	provider := &CoinGeckoPrices{
+		BaseURL: server.URL + "/api/v3/",
+		IDs:     map[string]string{"uatom": "cosmos", "uosmo": "osmosis", "unknown": "osmosis"},
+	}
+	prices, err := provider.Prices(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"uatom": 7.5, "uosmo": 0.5, "unknown": 0.5}, prices)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	_, err = provider.Prices(context.Background())
+	require.Error(t, err)
+}
+
+func TestCosmosDirectoryPrices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"chains": [{
+			"chain_id": "cosmoshub-4", "denom": "uatom", "display": "atom",
+			"prices": {"coingecko": {"atom": {"usd": 7.5}}},
+			"assets": [{"base": {"denom": "ibc/ABC"}, "display": {"denom": "osmo"}, "prices": {"coingecko": {"osmo": {"usd": 0.5}}}}]
+		}]}`))
+	}))
+	defer server.Close()
+
+	provider := &CosmosDirectoryPrices{Client: cosmosdirectory.NewClient(server.URL), ChainID: "cosmoshub-4"}
+	prices, err := provider.Prices(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"uatom": 7.5, "ibc/ABC": 0.5}, prices)
+
+	provider.ChainID = "unknown-1"
+	_, err = provider.Prices(context.Background())
+	require.ErrorIs(t, err, cosmosdirectory.ErrChainNotFound)
+}
+
+func TestFilePrices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("uatom: 7.5\nibc/ABC: 0.25\n"), 0o600))
+
+	provider := &FilePrices{Path: path}
+	prices, err := provider.Prices(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"uatom": 7.5, "ibc/ABC": 0.25}, prices)
+
+	// read again on every call
+	require.NoError(t, os.WriteFile(path, []byte(`{"uatom": 8}`), 0o600))
+	prices, err = provider.Prices(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"uatom": 8}, prices)
+
+	require.NoError(t, os.WriteFile(path, []byte("uatom: [1"), 0o600))
+	_, err = provider.Prices(context.Background())
+	require.Error(t, err)
+}
+
+func TestCachedPrices(t *testing.T) {
+	upstream := &fakePrices{name: "fake", prices: map[string]float64{"uatom": 7.5}}
+	provider := &CachedPrices{Provider: upstream, TTL: time.Hour}
+
+	for range 3 {
+		prices, err := provider.Prices(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 7.5, prices["uatom"])
+	}
+	require.Equal(t, int32(1), upstream.calls.Load())
+
+	// expired and failing, the last prices are kept
+	provider.TTL = 0
+	provider.ErrorTTL = time.Hour
+	upstream.prices, upstream.err = nil, errors.New("down")
+	prices, err := provider.Prices(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 7.5, prices["uatom"])
+	require.Equal(t, int32(2), upstream.calls.Load())
+
+	// the failure is remembered, the provider is left alone
+	for range 3 {
+		prices, err = provider.Prices(context.Background())
+		require.Error(t, err)
+		require.Equal(t, 7.5, prices["uatom"])
+	}
+	require.Equal(t, int32(2), upstream.calls.Load())
+
+	// asked again once the failure is old enough
+	provider.ErrorTTL = 0
+	upstream.prices, upstream.err = map[string]float64{"uatom": 8}, nil
+	prices, err = provider.Prices(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, float64(8), prices["uatom"])
+	require.Equal(t, int32(3), upstream.calls.Load())
+}
+
+func TestSingleHandlerPrices(t *testing.T) {
+	bank := &fakeWalletBank{balances: sdk.NewCoins(sdk.NewCoin("ustake", math.NewInt(3)))}
+	s := startFakeChain(t, func(server *grpc.Server) {
+		banktypes.RegisterQueryServer(server, bank)
+	})
+	s.Config.TokenPrice = true
+	s.Config.Denom = "ustake"
+	s.Config.Validators = []string{"cosmosvaloper1invalid"}
+	s.Config.Wallets = []string{sdk.AccAddress("wallet").String()}
+	s.Configure(s.Config)
+	s.Metrics = NewExporterMetrics(s.Config)
+	s.Denoms = NewDenomRegistry()
+	provider := &fakePrices{name: "fake", prices: map[string]float64{"ustake": 2}}
+	s.PriceProvider = provider
+
+	// the general, validators and wallets groups share the prices
+	s.SingleHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, int32(1), provider.calls.Load())
+}
+
+func TestObservedPrices(t *testing.T) {
+	config := &ServiceConfig{}
+	s := &Service{Log: zerolog.Nop(), Config: config, Metrics: NewExporterMetrics(config)}
+	provider := observedPrices{PriceProvider: &fakePrices{name: "cosmosdirectory", err: errors.New("down")}, s: s}
+
+	_, err := provider.Prices(context.Background())
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(s.Metrics.queryErrors.WithLabelValues("cosmosdirectory")))
+}
+
+func TestFallbackPrices(t *testing.T) {
+	first := &fakePrices{name: "first", prices: map[string]float64{"uatom": 7.5}}
+	second := &fakePrices{name: "second", prices: map[string]float64{"uatom": 1, "uosmo": 0.5}}
+	failing := &fakePrices{name: "failing", err: errors.New("down")}
+
+	prices, err := FallbackPrices{failing, first, second}.Prices(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"uatom": 7.5, "uosmo": 0.5}, prices)
+
+	_, err = FallbackPrices{failing, failing}.Prices(context.Background())
+	require.ErrorContains(t, err, "failing: down")
+}
+
+func TestNewPriceProvider(t *testing.T) {
+	s := &Service{}
+
+	provider, err := s.NewPriceProvider(&ServiceConfig{
+		PriceProviders: []string{"coingecko", "cosmosdirectory"},
+		CoinGeckoIDs:   []string{"uatom=cosmos"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "coingecko,cosmosdirectory", provider.Name())
+
+	_, err = s.NewPriceProvider(&ServiceConfig{PriceProviders: []string{"file"}})
+	require.Error(t, err)
+
+	_, err = s.NewPriceProvider(&ServiceConfig{PriceProviders: []string{"oracle"}})
+	require.Error(t, err)
+
+	_, err = s.NewPriceProvider(&ServiceConfig{CoinGeckoIDs: []string{"cosmos"}})
+	require.Error(t, err)
+}
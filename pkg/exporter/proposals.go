@@ -63,7 +63,7 @@ func NewValidatorVotingMetrics(reg prometheus.Registerer, config *ServiceConfig)
 	return m
 }
 
-func GetProposalsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ProposalsMetrics, s *Service, config *ServiceConfig, activeOnly bool) {
+func GetProposalsMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ProposalsMetrics, s *Service, config *ServiceConfig, activeOnly bool) {
 	if config.PropV1 {
 		wg.Add(1)
 		go func() {
@@ -74,16 +74,20 @@ func GetProposalsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics
 
 			govClient := govv1.NewQueryClient(s.GrpcConn)
 
-			var propReq govv1.QueryProposalsRequest
-			if activeOnly {
-				propReq = govv1.QueryProposalsRequest{ProposalStatus: govv1.StatusVotingPeriod, Pagination: &query.PageRequest{Reverse: true}}
-			} else {
-				propReq = govv1.QueryProposalsRequest{Pagination: &query.PageRequest{Reverse: true}}
-			}
-			proposalsResponse, err := govClient.Proposals(
-				context.Background(),
-				&propReq,
-			)
+			// every proposal in voting period, or the latest page of the whole history
+			proposals, err := Paginate(ctx, s, "gov.Proposals",
+				func(ctx context.Context, page *query.PageRequest) ([]*govv1.Proposal, *query.PageResponse, error) {
+					propReq := govv1.QueryProposalsRequest{Pagination: &query.PageRequest{Reverse: true}}
+					if activeOnly {
+						page.Reverse = true
+						propReq = govv1.QueryProposalsRequest{ProposalStatus: govv1.StatusVotingPeriod, Pagination: page}
+					}
+					res, err := govClient.Proposals(ctx, &propReq)
+					if !activeOnly {
+						return res.GetProposals(), nil, err
+					}
+					return res.GetProposals(), res.GetPagination(), err
+				})
 			if err != nil {
 				sublogger.Error().Err(err).Msg("Could not get proposals (v1-props)")
 				return
@@ -92,7 +96,6 @@ func GetProposalsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics
 			sublogger.Debug().
 				Float64("request-time", time.Since(queryStart).Seconds()).
 				Msg("Finished querying proposals")
-			proposals := proposalsResponse.Proposals
 
 			sublogger.Debug().
 				Int("proposalsLength", len(proposals)).
@@ -148,23 +151,25 @@ func GetProposalsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics
 		go func() {
 			defer wg.Done()
 
-			var proposals []govtypes.Proposal
-
 			sublogger.Debug().Msg("Started querying v1beta1 proposals")
 			queryStart := time.Now()
 
 			govClient := govtypes.NewQueryClient(s.GrpcConn)
 
-			var propReq govtypes.QueryProposalsRequest
-			if activeOnly {
-				propReq = govtypes.QueryProposalsRequest{ProposalStatus: govtypes.StatusVotingPeriod, Pagination: &query.PageRequest{Reverse: true}}
-			} else {
-				propReq = govtypes.QueryProposalsRequest{Pagination: &query.PageRequest{Reverse: true}}
-			}
-			proposalsResponse, err := govClient.Proposals(
-				context.Background(),
-				&propReq,
-			)
+			// every proposal in voting period, or the latest page of the whole history
+			proposals, err := Paginate(ctx, s, "gov.Proposals",
+				func(ctx context.Context, page *query.PageRequest) ([]govtypes.Proposal, *query.PageResponse, error) {
+					propReq := govtypes.QueryProposalsRequest{Pagination: &query.PageRequest{Reverse: true}}
+					if activeOnly {
+						page.Reverse = true
+						propReq = govtypes.QueryProposalsRequest{ProposalStatus: govtypes.StatusVotingPeriod, Pagination: page}
+					}
+					res, err := govClient.Proposals(ctx, &propReq)
+					if !activeOnly {
+						return res.GetProposals(), nil, err
+					}
+					return res.GetProposals(), res.GetPagination(), err
+				})
 			if err != nil {
 				sublogger.Error().Err(err).Msg("Could not get proposals (v1beta1-props)")
 				return
@@ -173,7 +178,6 @@ func GetProposalsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics
 			sublogger.Debug().
 				Float64("request-time", time.Since(queryStart).Seconds()).
 				Msg("Finished querying proposals")
-			proposals = proposalsResponse.Proposals
 
 			sublogger.Debug().
 				Int("proposalsLength", len(proposals)).
@@ -204,7 +208,7 @@ func GetProposalsMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics
 	}
 }
 
-func GetProposalsVoteMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorVotingMetrics, s *Service, _ *ServiceConfig, id uint64, validator types.ValAddress, wallet types.AccAddress) {
+func GetProposalsVoteMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorVotingMetrics, s *Service, config *ServiceConfig, id uint64, validator types.ValAddress, wallet types.AccAddress) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -216,16 +220,16 @@ func GetProposalsVoteMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metr
 
 		govClient := govtypes.NewQueryClient(s.GrpcConn)
 
-		voteReq := govtypes.QueryVoteRequest{ProposalId: id, Voter: wallet.String()}
+		voteReq := govtypes.QueryVoteRequest{ProposalId: id, Voter: config.AccAddressString(wallet)}
 
 		voteResponse, err := govClient.Vote(
-			context.Background(),
+			ctx,
 			&voteReq,
 		)
 		if err != nil {
 			metrics.validatorVoting.With(prometheus.Labels{
 				"id":          fmt.Sprintf("%d", id),
-				"validator":   validator.String(),
+				"validator":   config.ValAddressString(validator),
 				"voted":       "no",
 				"vote_option": "NOT_VOTED",
 			}).Set(float64(0))
@@ -246,7 +250,7 @@ func GetProposalsVoteMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metr
 		for _, voteOption := range voteResponse.Vote.Options {
 			metrics.validatorVoting.With(prometheus.Labels{
 				"id":          fmt.Sprintf("%d", id),
-				"validator":   validator.String(),
+				"validator":   config.ValAddressString(validator),
 				"voted":       "yes",
 				"vote_option": voteOption.Option.String(),
 			}).Set(float64(voteOption.Size()))
@@ -254,18 +258,21 @@ func GetProposalsVoteMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metr
 	}()
 }
 
-func (s *Service) GetActiveProposalsV1(sublogger *zerolog.Logger) ([]uint64, error) {
+func (s *Service) GetActiveProposalsV1(ctx context.Context, sublogger *zerolog.Logger) ([]uint64, error) {
 	sublogger.Debug().Msg("Started querying v1 proposals")
 	queryStart := time.Now()
 
 	govClient := govv1.NewQueryClient(s.GrpcConn)
 
-	propReq := govv1.QueryProposalsRequest{ProposalStatus: govv1.StatusVotingPeriod, Pagination: &query.PageRequest{Reverse: true}}
-
-	proposalsResponse, err := govClient.Proposals(
-		context.Background(),
-		&propReq,
-	)
+	activeProposals, err := Paginate(ctx, s, "gov.Proposals",
+		func(ctx context.Context, page *query.PageRequest) ([]*govv1.Proposal, *query.PageResponse, error) {
+			page.Reverse = true
+			res, err := govClient.Proposals(
+				ctx,
+				&govv1.QueryProposalsRequest{ProposalStatus: govv1.StatusVotingPeriod, Pagination: page},
+			)
+			return res.GetProposals(), res.GetPagination(), err
+		})
 	if err != nil {
 		sublogger.Error().Err(err).Msg("Could not get proposals-activeV1")
 		return nil, err
@@ -276,7 +283,7 @@ func (s *Service) GetActiveProposalsV1(sublogger *zerolog.Logger) ([]uint64, err
 		Msg("Finished querying proposals")
 
 	var proposals []uint64
-	for _, prop := range proposalsResponse.Proposals {
+	for _, prop := range activeProposals {
 		if prop.Status == govv1.ProposalStatus_PROPOSAL_STATUS_VOTING_PERIOD {
 			proposals = append(proposals, prop.Id)
 		}
@@ -284,18 +291,21 @@ func (s *Service) GetActiveProposalsV1(sublogger *zerolog.Logger) ([]uint64, err
 	return proposals, nil
 }
 
-func (s *Service) GetActiveProposals(sublogger *zerolog.Logger) ([]uint64, error) {
+func (s *Service) GetActiveProposals(ctx context.Context, sublogger *zerolog.Logger) ([]uint64, error) {
 	sublogger.Debug().Msg("Started querying v1 proposals")
 	queryStart := time.Now()
 
 	govClient := govtypes.NewQueryClient(s.GrpcConn)
 
-	propReq := govtypes.QueryProposalsRequest{ProposalStatus: govtypes.StatusVotingPeriod, Pagination: &query.PageRequest{Reverse: true}}
-
-	proposalsResponse, err := govClient.Proposals(
-		context.Background(),
-		&propReq,
-	)
+	activeProposals, err := Paginate(ctx, s, "gov.Proposals",
+		func(ctx context.Context, page *query.PageRequest) ([]govtypes.Proposal, *query.PageResponse, error) {
+			page.Reverse = true
+			res, err := govClient.Proposals(
+				ctx,
+				&govtypes.QueryProposalsRequest{ProposalStatus: govtypes.StatusVotingPeriod, Pagination: page},
+			)
+			return res.GetProposals(), res.GetPagination(), err
+		})
 	if err != nil {
 		sublogger.Error().Err(err).Msg("Could not get proposals-active")
 		return nil, err
@@ -305,7 +315,7 @@ func (s *Service) GetActiveProposals(sublogger *zerolog.Logger) ([]uint64, error
 		Float64("request-time", time.Since(queryStart).Seconds()).
 		Msg("Finished querying proposals")
 	var proposals []uint64
-	for _, prop := range proposalsResponse.Proposals {
+	for _, prop := range activeProposals {
 		if prop.Status == govtypes.StatusVotingPeriod {
 			proposals = append(proposals, prop.ProposalId)
 		}
@@ -323,12 +333,17 @@ func (s *Service) ProposalsHandler(w http.ResponseWriter, r *http.Request) {
 	registry := prometheus.NewRegistry()
 	proposalsMetrics := NewProposalsMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "proposals")
 	var wg sync.WaitGroup
 
-	GetProposalsMetrics(&wg, &sublogger, proposalsMetrics, s, s.Config, false)
+	GetProposalsMetrics(ctx, &wg, &sublogger, proposalsMetrics, s, s.Config, false)
 
 	wg.Wait()
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	s.FinishCollector(run)
+
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
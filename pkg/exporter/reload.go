@@ -0,0 +1,302 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Toggles are the settings applied again when the --config file changes, without restarting.
+type Toggles struct {
+	Validators    []string
+	ValidatorCons []string
+	Wallets       []string
+	Params        bool
+	Proposals     bool
+	Upgrades      bool
+	Oracle        bool
+	Votes         bool
+	PropV1        bool
+	TokenPrice    bool
+}
+
+func (config *ServiceConfig) Toggles() Toggles {
+	return Toggles{
+		Validators:    config.Validators,
+		ValidatorCons: config.ValidatorCons,
+		Wallets:       config.Wallets,
+		Params:        config.Params,
+		Proposals:     config.Proposals,
+		Upgrades:      config.Upgrades,
+		Oracle:        config.Oracle,
+		Votes:         config.Votes,
+		PropV1:        config.PropV1,
+		TokenPrice:    config.TokenPrice,
+	}
+}
+
+func (config *ServiceConfig) SetToggles(toggles Toggles) {
+	config.Validators = toggles.Validators
+	config.ValidatorCons = toggles.ValidatorCons
+	config.Wallets = toggles.Wallets
+	config.Params = toggles.Params
+	config.Proposals = toggles.Proposals
+	config.Upgrades = toggles.Upgrades
+	config.Oracle = toggles.Oracle
+	config.Votes = toggles.Votes
+	config.PropV1 = toggles.PropV1
+	config.TokenPrice = toggles.TokenPrice
+}
+
+// ApplyConfigFile sets the flags missing from the command line to their value in the config
+// file at path. The flags it sets are not marked as changed, so that Changed keeps telling
+// the flags passed on the command line, which LoadToggles must not override.
+func ApplyConfigFile(path string, flags *pflag.FlagSet) error {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+
+	// Credits to https://carolynvanslyck.com/blog/2020/08/sting-of-the-viper/
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || !v.IsSet(f.Name) {
+			return
+		}
+		if err = flags.Set(f.Name, fmt.Sprintf("%v", v.Get(f.Name))); err != nil {
+			err = fmt.Errorf("--%s: %w", f.Name, err)
+			return
+		}
+		f.Changed = false
+	})
+	return err
+}
+
+// LoadToggles reads the config file the same way it is read on startup: the flags passed on
+// the command line win over the file, and the keys missing from the file get the flag defaults.
+func LoadToggles(path string, flags *pflag.FlagSet) (Toggles, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return Toggles{}, err
+	}
+
+	var fresh ServiceConfig
+	cmd := &cobra.Command{}
+	fresh.SetCommonParameters(cmd)
+
+	var err error
+	cmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if err != nil {
+			return
+		}
+		current := flags.Lookup(f.Name)
+		switch {
+		case current != nil && current.Changed:
+			err = copyFlagValue(f, current)
+		case v.IsSet(f.Name):
+			err = f.Value.Set(fmt.Sprintf("%v", v.Get(f.Name)))
+		}
+		if err != nil {
+			err = fmt.Errorf("--%s: %w", f.Name, err)
+		}
+	})
+	if err != nil {
+		return Toggles{}, err
+	}
+
+	return fresh.Toggles(), nil
+}
+
+func copyFlagValue(dst, src *pflag.Flag) error {
+	// the String() of slices is bracketed and can not be passed back to Set
+	if srcSlice, ok := src.Value.(pflag.SliceValue); ok {
+		if dstSlice, ok := dst.Value.(pflag.SliceValue); ok {
+			return dstSlice.Replace(srcSlice.GetSlice())
+		}
+	}
+	return dst.Value.Set(src.Value.String())
+}
+
+// ApplyToggles swaps the toggles of the service. Requests and background refreshes
+// in flight finish with the previous ones.
+func (s *Service) ApplyToggles(toggles Toggles) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	previous := s.Config.Toggles()
+	s.Config.SetToggles(toggles)
+	s.Configure(s.Config)
+
+	logTogglesDiff(s.Log.Info(), previous, toggles).Msg("Reloaded config")
+}
+
+// snapshot returns a copy of the service holding the toggles as they are now. Long runs work
+// on it rather than holding configMu, which would hold up a reload and, behind the waiting
+// reload, every request.
+func (s *Service) snapshot() *Service {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+
+	config := *s.Config
+	view := &Service{
+		GrpcConn:         s.GrpcConn,
+		ExternalGrpcConn: s.ExternalGrpcConn,
+		Nodes:            s.Nodes,
+		Metrics:          s.Metrics,
+		Extensions:       s.Extensions,
+		Log:              s.Log,
+		Denoms:           s.Denoms,
+		DenomTraces:      s.DenomTraces,
+		PriceProvider:    s.PriceProvider,
+		Commissions:      s.Commissions,
+		ValidatorInfos:   s.ValidatorInfos,
+		SlashHeights:     s.SlashHeights,
+	}
+	view.Configure(&config)
+	return view
+}
+
+// ReloadConfig reads the toggles from the --config file and applies them.
+func (s *Service) ReloadConfig(flags *pflag.FlagSet) error {
+	toggles, err := LoadToggles(s.Config.ConfigPath, flags)
+	s.ObserveReload(err)
+	if err != nil {
+		return err
+	}
+
+	s.ApplyToggles(toggles)
+	return nil
+}
+
+func logTogglesDiff(event *zerolog.Event, previous, current Toggles) *zerolog.Event {
+	lists := []struct {
+		name              string
+		previous, current []string
+	}{
+		{"validators", previous.Validators, current.Validators},
+		{"validatorcons", previous.ValidatorCons, current.ValidatorCons},
+		{"wallets", previous.Wallets, current.Wallets},
+	}
+	for _, list := range lists {
+		added, removed := diffStrings(list.previous, list.current)
+		if len(added) > 0 {
+			event = event.Strs(list.name+"-added", added)
+		}
+		if len(removed) > 0 {
+			event = event.Strs(list.name+"-removed", removed)
+		}
+	}
+
+	toggles := []struct {
+		name              string
+		previous, current bool
+	}{
+		{"--params", previous.Params, current.Params},
+		{"--proposals", previous.Proposals, current.Proposals},
+		{"--upgrades", previous.Upgrades, current.Upgrades},
+		{"--oracle", previous.Oracle, current.Oracle},
+		{"--votes", previous.Votes, current.Votes},
+		{"--propv1", previous.PropV1, current.PropV1},
+		{"--price", previous.TokenPrice, current.TokenPrice},
+	}
+	for _, toggle := range toggles {
+		if toggle.previous != toggle.current {
+			event = event.Bool(toggle.name, toggle.current)
+		}
+	}
+
+	return event
+}
+
+// diffStrings returns the entries of current missing from previous, and the other way round.
+func diffStrings(previous, current []string) (added, removed []string) {
+	inPrevious := make(map[string]bool, len(previous))
+	for _, entry := range previous {
+		inPrevious[entry] = true
+	}
+	inCurrent := make(map[string]bool, len(current))
+	for _, entry := range current {
+		inCurrent[entry] = true
+		if !inPrevious[entry] {
+			added = append(added, entry)
+		}
+	}
+	for _, entry := range previous {
+		if !inCurrent[entry] {
+			removed = append(removed, entry)
+		}
+	}
+	return added, removed
+}
+
+// WatchConfig calls reload when the file at path changes or the process gets SIGHUP,
+// until ctx is cancelled. Bursts of file events, as written by editors, trigger one reload.
+func WatchConfig(ctx context.Context, log zerolog.Logger, path string, reload func()) {
+	var events chan fsnotify.Event
+	var errors chan error
+
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		// the directory is watched, editors and config management replace the file
+		if err = watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Could not watch config file, it is only reloaded on SIGHUP")
+	} else {
+		events, errors = watcher.Events, watcher.Errors
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		if events != nil {
+			defer watcher.Close()
+		}
+
+		target := filepath.Clean(path)
+		debounce := time.NewTimer(0)
+		<-debounce.C
+
+		for {
+			select {
+			case <-ctx.Done():
+				debounce.Stop()
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == target && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+					debounce.Reset(500 * time.Millisecond)
+				}
+			case err, ok := <-errors:
+				if !ok {
+					return
+				}
+				log.Error().Err(err).Msg("Error watching config file")
+			case <-hup:
+				log.Info().Msg("Got SIGHUP, reloading config")
+				reload()
+			case <-debounce.C:
+				log.Info().Str("path", path).Msg("Config file changed, reloading config")
+				reload()
+			}
+		}
+	}()
+}
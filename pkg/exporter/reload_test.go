@@ -0,0 +1,165 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"cosmossdk.io/math"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+func TestLoadToggles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+validators = "cosmosvaloper1a,cosmosvaloper1b"
+wallets = "cosmos1file"
+params = true
+`), 0o600))
+
+	var config ServiceConfig
+	cmd := &cobra.Command{}
+	config.SetCommonParameters(cmd)
+	require.NoError(t, cmd.ParseFlags([]string{"--wallets=cosmos1flag", "--proposals"}))
+
+	toggles, err := LoadToggles(path, cmd.Flags())
+	require.NoError(t, err)
+	require.Equal(t, []string{"cosmosvaloper1a", "cosmosvaloper1b"}, toggles.Validators)
+	require.True(t, toggles.Params)
+	// the command line wins over the file
+	require.Equal(t, []string{"cosmos1flag"}, toggles.Wallets)
+	require.True(t, toggles.Proposals)
+	// missing from the file and the command line
+	require.False(t, toggles.Upgrades)
+	require.True(t, toggles.TokenPrice)
+
+	require.NoError(t, os.WriteFile(path, []byte(`params = "maybe"`), 0o600))
+	_, err = LoadToggles(path, cmd.Flags())
+	require.Error(t, err)
+}
+
+func TestReloadAfterStartup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+validators = "a"
+wallets = "cosmos1file"
+params = true
+`), 0o600))
+
+	// as on startup: the command line, then the file for the flags it did not set
+	var config ServiceConfig
+	cmd := &cobra.Command{}
+	config.SetCommonParameters(cmd)
+	require.NoError(t, cmd.ParseFlags([]string{"--wallets=cosmos1flag"}))
+	require.NoError(t, ApplyConfigFile(path, cmd.Flags()))
+	require.Equal(t, []string{"a"}, config.Validators)
+	require.True(t, config.Params)
+	require.Equal(t, []string{"cosmos1flag"}, config.Wallets)
+
+	s := &Service{Log: zerolog.Nop()}
+	config.ConfigPath = path
+	s.Configure(&config)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+validators = "b,c"
+wallets = "cosmos1file"
+params = false
+`), 0o600))
+	require.NoError(t, s.ReloadConfig(cmd.Flags()))
+	require.Equal(t, []string{"b", "c"}, s.Validators)
+	require.False(t, s.Params)
+	// still the command line
+	require.Equal(t, []string{"cosmos1flag"}, s.Wallets)
+}
+
+func TestSnapshot(t *testing.T) {
+	config := &ServiceConfig{Validators: []string{"a"}, Params: true}
+	s := &Service{Log: zerolog.Nop()}
+	s.Configure(config)
+
+	view := s.snapshot()
+	// the lock is not held while the snapshot is used
+	s.ApplyToggles(Toggles{Validators: []string{"b"}})
+
+	require.Equal(t, []string{"a"}, view.Validators)
+	require.True(t, view.Config.Params)
+	require.Equal(t, []string{"b"}, s.Validators)
+	require.False(t, s.Config.Params)
+}
+
+func TestReloadDuringRefresh(t *testing.T) {
+	bank := &fakeWalletBank{balances: sdk.NewCoins(sdk.NewCoin("ustake", math.NewInt(3)))}
+	s := startFakeChain(t, func(server *grpc.Server) {
+		banktypes.RegisterQueryServer(server, bank)
+	})
+	s.Config.Wallets = []string{sdk.AccAddress("wallet").String()}
+	s.Configure(s.Config)
+	s.Metrics = NewExporterMetrics(s.Config)
+	p := NewPoller(s)
+
+	var wallets singleGroup
+	for _, group := range singleGroups {
+		if group.name == GroupWallets {
+			wallets = group
+		}
+	}
+
+	// run with -race, the refresh must not read the toggles being swapped
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			s.ApplyToggles(Toggles{Wallets: []string{sdk.AccAddress(fmt.Sprintf("wallet%d", i)).String()}})
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		p.refresh(context.Background(), wallets, time.Second)
+	}
+	<-done
+
+	require.True(t, p.hasSnapshot(GroupWallets))
+}
+
+func TestApplyToggles(t *testing.T) {
+	config := &ServiceConfig{Validators: []string{"a", "b"}, Params: true}
+	s := &Service{Log: zerolog.Nop()}
+	s.Configure(config)
+
+	s.ApplyToggles(Toggles{Validators: []string{"b", "c"}, Wallets: []string{"w"}})
+	require.Equal(t, []string{"b", "c"}, s.Validators)
+	require.Equal(t, []string{"w"}, s.Wallets)
+	require.False(t, s.Params)
+	require.Equal(t, []string{"b", "c"}, config.Validators)
+
+	added, removed := diffStrings([]string{"a", "b"}, []string{"b", "c"})
+	require.Equal(t, []string{"c"}, added)
+	require.Equal(t, []string{"a"}, removed)
+}
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`params = true`), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloaded := make(chan struct{}, 1)
+	WatchConfig(ctx, zerolog.Nop(), path, func() { reloaded <- struct{}{} })
+
+	require.NoError(t, os.WriteFile(path, []byte(`params = false`), 0o600))
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("config file change did not trigger a reload")
+	}
+}
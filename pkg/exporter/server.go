@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/exporter-toolkit/web"
+	"github.com/rs/zerolog"
+)
+
+// ListenAndServe serves handler on --listen-address, with the TLS and basic auth settings of
+// the --web-config-file if any, until ctx is cancelled. The requests in flight are then given
+// --shutdown-timeout to finish.
+func ListenAndServe(ctx context.Context, config *ServiceConfig, log zerolog.Logger, handler http.Handler) error {
+	if config.WebConfigFile != "" {
+		if err := web.Validate(config.WebConfigFile); err != nil {
+			return fmt.Errorf("web config file: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", config.ListenAddress)
+	if err != nil {
+		return err
+	}
+
+	return Serve(ctx, listener, config, log, handler)
+}
+
+// Serve is ListenAndServe on an existing listener.
+func Serve(ctx context.Context, listener net.Listener, config *ServiceConfig, log zerolog.Logger, handler http.Handler) error {
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: config.ReadTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+	}
+	flags := &web.FlagConfig{
+		WebListenAddresses: &[]string{config.ListenAddress},
+		WebSystemdSocket:   new(bool),
+		WebConfigFile:      &config.WebConfigFile,
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- web.Serve(listener, server, flags, kitLogger{log: log})
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info().Dur("timeout", config.ShutdownTimeout).Msg("Shutting down, waiting for the requests in flight")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// kitLogger passes the logs of the exporter toolkit, written as go-kit key/value pairs, to zerolog.
+type kitLogger struct {
+	log zerolog.Logger
+}
+
+func (l kitLogger) Log(keyvals ...interface{}) error {
+	fields := make(map[string]interface{}, len(keyvals)/2)
+	var msg string
+	event := l.log.Info()
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		switch key {
+		case "msg":
+			msg = fmt.Sprint(keyvals[i+1])
+		case "level":
+			if level, err := zerolog.ParseLevel(fmt.Sprint(keyvals[i+1])); err == nil {
+				event = l.log.WithLevel(level)
+			}
+		default:
+			fields[key] = keyvals[i+1]
+		}
+	}
+	event.Fields(fields).Msg(msg)
+	return nil
+}
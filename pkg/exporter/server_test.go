@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeBasicAuth(t *testing.T) {
+	// bcrypt hash of "secret"
+	webConfig := filepath.Join(t.TempDir(), "web.yml")
+	require.NoError(t, os.WriteFile(webConfig, []byte(`
+basic_auth_users:
+  prometheus: $2a$04$bhNiXtRYMnukH9gMklH2euDa1XbaavJuCWDCNPYeS8jP14xbwTMfu
+`), 0o600))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	config := &ServiceConfig{
+		ListenAddress:   listener.Addr().String(),
+		WebConfigFile:   webConfig,
+		ReadTimeout:     time.Second,
+		WriteTimeout:    time.Second,
+		ShutdownTimeout: time.Second,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- Serve(ctx, listener, config, zerolog.Nop(), mux) }()
+
+	url := "http://" + listener.Addr().String() + "/metrics"
+	tests := []struct {
+		User     string
+		Password string
+		Status   int
+	}{
+		{Status: http.StatusUnauthorized},
+		{User: "prometheus", Password: "wrong", Status: http.StatusUnauthorized},
+		{User: "prometheus", Password: "secret", Status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		if tt.User != "" {
+			request.SetBasicAuth(tt.User, tt.Password)
+		}
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		response.Body.Close()
+		require.Equal(t, tt.Status, response.StatusCode, tt.User+":"+tt.Password)
+	}
+
+	cancel()
+	select {
+	case err := <-served:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
@@ -2,32 +2,48 @@ package exporter
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"math"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
-
 	tmservice "github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+
+	"github.com/pfc-developer/cosmos-exporter/pkg/cosmosdirectory"
 )
 
 type ServiceConfig struct {
 	ConfigPath string
+	// ChainsPath is a YAML file listing the chains to serve from this process
+	ChainsPath string
 
 	Denom         string
 	ListenAddress string
-	NodeAddress   string
-	TendermintRPC string // needed to get upgrade info
-	LogLevel      string
-	JSONOutput    bool
-	Limit         uint64
+	// WebConfigFile enables TLS and basic auth on the listener, in the Prometheus exporter format
+	WebConfigFile   string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+	// /readyz fails when the latest block of the node is older than ReadyMaxBlockAge
+	ReadyMaxBlockAge time.Duration
+	NodeAddresses    []string
+	// a node more than NodeMaxLag blocks behind the highest endpoint is not failed over to
+	NodeMaxLag         int64
+	NodeHealthInterval time.Duration
+	NodeGrpc           GrpcClientConfig
+	TendermintRPC      string // needed to get upgrade info
+	LogLevel           string
+	JSONOutput         bool
+	Limit              uint64
+	// MaxPages caps the pages read by a single list query
+	MaxPages int
 
 	Prefix                    string
 	AccountPrefix             string
@@ -43,26 +59,64 @@ type ServiceConfig struct {
 	ConstLabels      map[string]string
 	DenomCoefficient float64
 	DenomExponent    uint64
+	// DenomMetadata adds or overrides the display units of coins, as <base>=<display>:<exponent>
+	DenomMetadata []string
 
 	// SingleReq bundle up multiple requests into a single /metrics
-	SingleReq     bool
-	Wallets       []string
-	Validators    []string
-	Oracle        bool
-	Upgrades      bool
-	Proposals     bool
-	Params        bool
-	TokenPrice    bool
-	PropV1        bool
-	Votes         bool
-	ExternalGrpc  string
-	Initia        bool // little bit hacky I know
-	ValidatorCons []string
+	SingleReq        bool
+	Wallets          []string
+	Validators       []string
+	Oracle           bool
+	Upgrades         bool
+	Proposals        bool
+	Params           bool
+	TokenPrice       bool
+	PropV1           bool
+	Votes            bool
+	ExternalGrpc     string
+	ExternalNodeGrpc GrpcClientConfig
+	LCD              string   // REST endpoint, used by some extensions
+	Extensions       []string // names of the chain extensions to enable
+	Initia           bool     // little bit hacky I know
+	ValidatorCons    []string
+
+	// PriceProviders are asked for USD prices in order, the first one knowing a coin wins
+	PriceProviders []string
+	PriceCacheTTL  time.Duration
+	CoinGeckoURL   string
+	// CosmosDirectoryURL serves the chain list of the cosmosdirectory price provider
+	CosmosDirectoryURL string
+	// CoinGeckoIDs maps base denoms to CoinGecko ids, as <base denom>=<id>
+	CoinGeckoIDs []string
+	PriceFile    string
+
+	// QueryTimeout bounds every single upstream gRPC query
+	QueryTimeout time.Duration
+	// ScrapeTimeoutOffset is subtracted from the Prometheus scrape timeout to leave time to reply
+	ScrapeTimeoutOffset time.Duration
+
+	// Poll refreshes the single mode groups in the background and serves cached snapshots
+	Poll                   bool
+	PollGeneralInterval    time.Duration
+	PollParamsInterval     time.Duration
+	PollValidatorsInterval time.Duration
+	PollWalletsInterval    time.Duration
+	PollProposalsInterval  time.Duration
+	PollUpgradesInterval   time.Duration
+
+	// BlockWatcher follows the blocks of TendermintRPC to record the signatures of Validators
+	BlockWatcher         bool
+	BlockWatcherWindow   int
+	BlockWatcherInterval time.Duration
 }
 
 type Service struct {
-	GrpcConn         *grpc.ClientConn
+	// GrpcConn sends the queries to the active endpoint of Nodes
+	GrpcConn         grpc.ClientConnInterface
 	ExternalGrpcConn *grpc.ClientConn
+	Nodes            *NodePool
+	Metrics          *ExporterMetrics
+	Extensions       []ChainExtension
 	//	TmRPC      *tmrpc.HTTP
 	Wallets    []string
 	Validators []string
@@ -74,6 +128,23 @@ type Service struct {
 	Log        zerolog.Logger
 	// only used in Initia for now
 	ValidatorCons []string
+	// Denoms scales every coin to its display unit
+	Denoms *DenomRegistry
+	// DenomTraces resolves IBC denoms to their origin
+	DenomTraces *DenomTraces
+	// PriceProvider values the coins in USD when --price is on
+	PriceProvider PriceProvider
+	// Commissions counts the commission changes seen by the validator handlers
+	Commissions *CommissionTracker
+	// ValidatorInfos counts the description and consensus key changes of the monitored validators
+	ValidatorInfos *ValidatorInfoTracker
+	// SlashHeights caches the height of the last slash of the monitored validators
+	SlashHeights *SlashHeights
+
+	// configMu is held for reading by every request, and for writing when the toggles are
+	// reloaded, so that a run sees a single config. Background refreshes work on a snapshot
+	// taken under it instead
+	configMu sync.RWMutex
 }
 
 func (s *Service) SetChainID(config *ServiceConfig) {
@@ -91,38 +162,108 @@ func (s *Service) SetChainID(config *ServiceConfig) {
 	config.ConstLabels = map[string]string{
 		"chain_id": config.ChainID,
 	}
+	// created once the chain id is known, so that the exporter metrics of the chains
+	// served by one process do not collide
+	s.Metrics = NewExporterMetrics(config)
+	s.Commissions = NewCommissionTracker(s.Metrics.registry, config, s.Log)
+	s.ValidatorInfos = NewValidatorInfoTracker(s.Metrics.registry, config, s.Log)
+	s.SlashHeights = NewSlashHeights()
+	if s.Nodes != nil {
+		s.Metrics.registry.MustRegister(newNodePoolCollector(s.Nodes, config.ConstLabels))
+	}
+}
+
+// Configure points the service at its config and copies the feature toggles.
+func (s *Service) Configure(config *ServiceConfig) {
+	s.Config = config
+	s.Params = config.Params
+	s.Wallets = config.Wallets
+	s.Validators = config.Validators
+	s.Proposals = config.Proposals
+	s.Oracle = config.Oracle
+	s.Upgrades = config.Upgrades
+	s.ValidatorCons = config.ValidatorCons
+}
+
+// Routes returns the handlers of the service keyed by their path below /metrics.
+// The empty path is the single mode endpoint, present only if single or poll mode is on.
+func (s *Service) Routes(ctx context.Context) map[string]http.HandlerFunc {
+	routes := map[string]http.HandlerFunc{
+		"/wallet":     s.WalletHandler,
+		"/validator":  s.ValidatorHandler,
+		"/validators": s.ValidatorsHandler,
+		"/params":     s.ParamsHandler,
+		"/general":    s.GeneralHandler,
+		"/delegator":  s.DelegatorHandler,
+		"/proposals":  s.ProposalsHandler,
+		"/upgrade":    s.UpgradeHandler,
+	}
+	for _, ext := range s.Extensions {
+		for path, handler := range ext.Routes(s) {
+			routes[path] = handler
+		}
+	}
+	if s.Config.SingleReq && !s.Config.Poll {
+		s.Log.Info().Msg("Starting Single Mode")
+		routes[""] = s.SingleHandler
+	}
+	for path, handler := range routes {
+		routes[path] = s.withConfigLock(handler)
+	}
+
+	// the snapshots are served without waiting for a reload, the refreshes copy the toggles
+	if s.Config.Poll {
+		s.Log.Info().Msg("Starting Single Mode with background polling")
+		poller := NewPoller(s)
+		poller.Start(ctx)
+		routes[""] = poller.Handler
+	}
+
+	if s.Config.BlockWatcher {
+		watcher, err := NewBlockWatcher(s)
+		if err != nil {
+			s.Log.Error().Err(err).Msg("Could not start the block watcher")
+		} else {
+			watcher.Start(ctx)
+		}
+	}
+
+	return routes
+}
+
+// withConfigLock keeps the toggles from being reloaded while handler runs.
+func (s *Service) withConfigLock(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.configMu.RLock()
+		defer s.configMu.RUnlock()
+		handler(w, r)
+	}
 }
 
 func (s *Service) Connect(config *ServiceConfig) error {
 	var err error
-	creds := grpc.WithTransportCredentials(insecure.NewCredentials())
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
-	if strings.Contains(config.NodeAddress, ":443") {
-		creds = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
-	}
+	interceptor := grpc.WithUnaryInterceptor(s.unaryInterceptor)
 
-	s.GrpcConn, err = grpc.DialContext(ctx,
-		config.NodeAddress,
-		creds)
-	// grpc.WithTransportCredentials(insecure.NewCredentials()))
-	// grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+	s.Nodes, err = NewNodePool(s, config.NodeAddresses, config.NodeGrpc, config.NodeMaxLag, config.NodeHealthInterval, interceptor)
 	if err != nil {
 		return err
 	}
+	s.GrpcConn = s.Nodes
 
 	if config.ExternalGrpc == "" {
 		s.ExternalGrpcConn = nil
 	} else {
-		creds = grpc.WithTransportCredentials(insecure.NewCredentials())
-
-		if strings.Contains(config.ExternalGrpc, ":443") {
-			creds = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+		opts, err := config.ExternalNodeGrpc.DialOptions(config.ExternalGrpc)
+		if err != nil {
+			return fmt.Errorf("external node %s: %w", config.ExternalGrpc, err)
 		}
 
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
 		s.ExternalGrpcConn, err = grpc.DialContext(ctx,
 			config.ExternalGrpc,
-			creds)
-		// grpc.WithTransportCredentials(insecure.NewCredentials()))
+			append(opts, interceptor)...)
 		if err != nil {
 			return err
 		}
@@ -131,32 +272,73 @@ func (s *Service) Connect(config *ServiceConfig) error {
 }
 
 func (s *Service) Close() error {
-	err := s.GrpcConn.Close()
+	err := s.Nodes.Close()
+	if s.ExternalGrpcConn != nil {
+		if externalErr := s.ExternalGrpcConn.Close(); externalErr != nil && err == nil {
+			err = externalErr
+		}
+	}
 	return err
 }
 
 func (s *Service) SetDenom(config *ServiceConfig) {
+	s.Denoms = NewDenomRegistry()
+	s.DenomTraces = NewDenomTraces()
+
 	// if --denom and (--denom-coefficient or --denom-exponent) are provided, use them
 	// instead of fetching them via gRPC. Can be useful for networks like osmosis.
-	if isUserProvidedAndHandled := s.checkAndHandleDenomInfoProvidedByUser(config); isUserProvidedAndHandled {
-		return
-	}
+	isUserProvidedAndHandled := s.checkAndHandleDenomInfoProvidedByUser(config)
 
-	bankClient := banktypes.NewQueryClient(s.GrpcConn)
-	denoms, err := bankClient.DenomsMetadata(
-		context.Background(),
-		&banktypes.QueryDenomsMetadataRequest{},
-	)
+	metadatas, err := s.LoadDenomMetadata(context.Background())
 	if err != nil {
-		s.Log.Fatal().Err(err).Msg("Error querying denom")
+		if !isUserProvidedAndHandled {
+			s.Log.Fatal().Err(err).Msg("Error querying denom")
+		}
+		s.Log.Warn().Err(err).Msg("Could not query denoms metadata, coins other than the staking one are not scaled")
+	}
+	for _, metadata := range metadatas {
+		s.Denoms.AddMetadata(metadata)
 	}
 
-	if len(denoms.Metadatas) == 0 {
-		s.Log.Fatal().Msg("No denom infos. Try running the binary with --denom and --denom-coefficient to set them manually.")
+	bondDenom := s.getBondDenom(config)
+	if !isUserProvidedAndHandled {
+		if len(metadatas) == 0 {
+			s.Log.Fatal().Msg("No denom infos. Try running the binary with --denom and --denom-coefficient to set them manually.")
+		}
+
+		metadata := metadatas[0] // the staking denom, or the first one
+		for _, metadataIterated := range metadatas {
+			if metadataIterated.Base == bondDenom {
+				metadata = metadataIterated
+				break
+			}
+		}
+		s.setDenomFromMetadata(config, metadata)
+	}
+
+	// the staking denom is displayed as --denom, even if its metadata says otherwise
+	if bondDenom == "" {
+		bondDenom = s.Denoms.Base(config.Denom)
+	}
+	s.Denoms.Set(DenomUnit{Base: bondDenom, Display: config.Denom, Coefficient: config.DenomCoefficient})
+
+	for _, entry := range config.DenomMetadata {
+		unit, err := ParseDenomUnit(entry)
+		if err != nil {
+			s.Log.Fatal().Err(err).Msg("Invalid --denom-metadata")
+		}
+		s.Denoms.Set(unit)
 	}
 
-	metadata := denoms.Metadatas[0] // always using the first one
-	if config.Denom == "" {         // using display currency
+	s.Log.Info().
+		Str("denom", config.Denom).
+		Str("base-denom", bondDenom).
+		Int("denoms", len(metadatas)).
+		Msg("Loaded denoms metadata")
+}
+
+func (s *Service) setDenomFromMetadata(config *ServiceConfig, metadata banktypes.Metadata) {
+	if config.Denom == "" { // using display currency
 		config.Denom = metadata.Display
 	}
 
@@ -178,6 +360,25 @@ func (s *Service) SetDenom(config *ServiceConfig) {
 	s.Log.Fatal().Msg("Could not find the denom info")
 }
 
+// getBondDenom returns the base denom of the staking module, "" if it is unknown.
+func (s *Service) getBondDenom(config *ServiceConfig) string {
+	// initia replaced the staking module
+	if config.Initia {
+		return ""
+	}
+
+	stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
+	response, err := stakingClient.Params(
+		context.Background(),
+		&stakingtypes.QueryParamsRequest{},
+	)
+	if err != nil {
+		s.Log.Warn().Err(err).Msg("Could not get the staking denom")
+		return ""
+	}
+	return response.Params.BondDenom
+}
+
 func (s *Service) checkAndHandleDenomInfoProvidedByUser(config *ServiceConfig) bool {
 	if config.Denom != "" {
 		if config.DenomCoefficient != 1 && config.DenomExponent != 0 {
@@ -208,10 +409,10 @@ func (s *Service) checkAndHandleDenomInfoProvidedByUser(config *ServiceConfig) b
 	return false
 }
 
-func (s *Service) GetLatestBlock(conn *grpc.ClientConn) (float64, error) {
+func (s *Service) GetLatestBlock(ctx context.Context, conn grpc.ClientConnInterface) (float64, error) {
 	serviceClient := tmservice.NewServiceClient(conn)
 	response, err := serviceClient.GetLatestBlock(
-		context.Background(),
+		ctx,
 		&tmservice.GetLatestBlockRequest{},
 	)
 	if err != nil {
@@ -225,16 +426,30 @@ func (s *Service) GetLatestBlock(conn *grpc.ClientConn) (float64, error) {
 
 func (config *ServiceConfig) SetCommonParameters(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(&config.ConfigPath, "config", "", "Config file path")
+	cmd.PersistentFlags().StringVar(&config.ChainsPath, "chains", "", "YAML file listing the chains to serve, each on /metrics/<chain>")
 	cmd.PersistentFlags().StringVar(&config.Denom, "denom", "", "Cosmos coin denom")
 	cmd.PersistentFlags().Float64Var(&config.DenomCoefficient, "denom-coefficient", 1, "Denom coefficient")
 	cmd.PersistentFlags().Uint64Var(&config.DenomExponent, "denom-exponent", 0, "Denom exponent")
+	cmd.PersistentFlags().StringSliceVar(&config.DenomMetadata, "denom-metadata", nil, "Display unit of a coin, as <base>=<display>:<exponent>, overriding the bank metadata of the chain. Can be repeated")
 	cmd.PersistentFlags().StringVar(&config.ListenAddress, "listen-address", ":9300", "The address this exporter would listen on")
-	cmd.PersistentFlags().StringVar(&config.NodeAddress, "node", "localhost:9090", "GRPC node address")
+	cmd.PersistentFlags().StringVar(&config.WebConfigFile, "web-config-file", "", "Prometheus exporter web config file enabling TLS and basic auth")
+	cmd.PersistentFlags().DurationVar(&config.ReadTimeout, "http-read-timeout", 10*time.Second, "Timeout to read a request to the exporter")
+	cmd.PersistentFlags().DurationVar(&config.WriteTimeout, "http-write-timeout", 2*time.Minute, "Timeout to write a response of the exporter, must exceed the slowest scrape")
+	cmd.PersistentFlags().DurationVar(&config.ShutdownTimeout, "shutdown-timeout", 30*time.Second, "How long the requests in flight may take to finish on SIGTERM")
+	cmd.PersistentFlags().DurationVar(&config.ReadyMaxBlockAge, "ready-max-block-age", time.Minute, "/readyz fails when the latest block of the node is older than this")
+	cmd.PersistentFlags().StringSliceVar(&config.NodeAddresses, "node", []string{"localhost:9090"}, "GRPC node address, pass several to fail over between them")
+	cmd.PersistentFlags().Int64Var(&config.NodeMaxLag, "node-max-lag", 5, "blocks a node may be behind the highest --node endpoint and still be failed over to")
+	cmd.PersistentFlags().DurationVar(&config.NodeHealthInterval, "node-health-interval", 15*time.Second, "how often the --node endpoints are checked when several are passed")
 	cmd.PersistentFlags().StringVar(&config.ExternalGrpc, "external-node", "", "GRPC node address")
+	registerGrpcClientFlags(cmd.PersistentFlags(), "node", &config.NodeGrpc)
+	registerGrpcClientFlags(cmd.PersistentFlags(), "external-node", &config.ExternalNodeGrpc)
 	cmd.PersistentFlags().StringVar(&config.LogLevel, "log-level", "info", "Logging level")
 	cmd.PersistentFlags().Uint64Var(&config.Limit, "limit", 1000, "Pagination limit for gRPC requests")
+	cmd.PersistentFlags().IntVar(&config.MaxPages, "max-pages", 500, "Maximum number of pages read by a single list query (0 for no limit)")
 	cmd.PersistentFlags().StringVar(&config.TendermintRPC, "tendermint-rpc", "http://localhost:26657", "Tendermint RPC address")
 	cmd.PersistentFlags().BoolVar(&config.JSONOutput, "json", false, "Output logs as JSON")
+	cmd.PersistentFlags().DurationVar(&config.QueryTimeout, "query-timeout", 10*time.Second, "Timeout of a single gRPC query (0 to disable)")
+	cmd.PersistentFlags().DurationVar(&config.ScrapeTimeoutOffset, "scrape-timeout-offset", 500*time.Millisecond, "Subtracted from the Prometheus scrape timeout header to leave time to send the response")
 
 	// some networks, like Iris, have the different prefixes for address, validator and consensus node
 	cmd.PersistentFlags().StringVar(&config.Prefix, "bech-prefix", "persistence", "Bech32 global prefix")
@@ -250,11 +465,50 @@ func (config *ServiceConfig) SetCommonParameters(cmd *cobra.Command) {
 	cmd.PersistentFlags().BoolVar(&config.Proposals, "proposals", false, "serve active proposal info in the single call to /metrics")
 	cmd.PersistentFlags().BoolVar(&config.Params, "params", false, "serve chain params info in the single call to /metrics")
 	cmd.PersistentFlags().BoolVar(&config.TokenPrice, "price", true, "fetch token price")
+	cmd.PersistentFlags().StringSliceVar(&config.PriceProviders, "price-providers", []string{"cosmosdirectory"}, "price providers asked in order, out of cosmosdirectory, coingecko and file")
+	cmd.PersistentFlags().DurationVar(&config.PriceCacheTTL, "price-cache-ttl", 5*time.Minute, "how long the prices of a provider are reused")
+	cmd.PersistentFlags().StringVar(&config.CosmosDirectoryURL, "cosmos-directory-url", cosmosdirectory.CosmosDirectoryURL, "chain list of the cosmosdirectory price provider")
+	cmd.PersistentFlags().StringVar(&config.CoinGeckoURL, "coingecko-url", "https://api.coingecko.com/api/v3", "root of the CoinGecko compatible API of the coingecko price provider")
+	cmd.PersistentFlags().StringSliceVar(&config.CoinGeckoIDs, "coingecko-ids", nil, "CoinGecko id of a coin, as <base denom>=<id>, e.g. uatom=cosmos. Can be repeated")
+	cmd.PersistentFlags().StringVar(&config.PriceFile, "price-file", "", "YAML or JSON file mapping base denoms to USD prices, for the file price provider")
 	cmd.PersistentFlags().StringSliceVar(&config.Wallets, "wallets", nil, "serve info about passed wallets")
 	cmd.PersistentFlags().StringSliceVar(&config.Validators, "validators", nil, "serve info about passed validators")
 	cmd.PersistentFlags().StringSliceVar(&config.ValidatorCons, "validatorcons", nil, "serve info about passed validatorcons (initia only)")
 	cmd.PersistentFlags().BoolVar(&config.PropV1, "propv1", false, "use PropV1 instead of PropV1Beta calls")
 	cmd.PersistentFlags().BoolVar(&config.Votes, "votes", false, "get validator votes on active proposals")
+	cmd.PersistentFlags().BoolVar(&config.Oracle, "oracle", false, "serve the oracle info of the enabled extensions in the single call to /metrics")
+	cmd.PersistentFlags().StringSliceVar(&config.Extensions, "extensions", nil, "chain extensions to enable, e.g. sei,kujira")
+	cmd.PersistentFlags().StringVar(&config.LCD, "lcd", "http://localhost:1317", "LCD endpoint, used by the injective and pryzm extensions")
+
+	cmd.PersistentFlags().BoolVar(&config.Poll, "poll", false, "refresh the single mode metrics in the background and serve the last snapshot on /metrics")
+	cmd.PersistentFlags().DurationVar(&config.PollGeneralInterval, "poll-general-interval", 30*time.Second, "refresh interval of the general metrics in poll mode")
+	cmd.PersistentFlags().DurationVar(&config.PollParamsInterval, "poll-params-interval", 10*time.Minute, "refresh interval of the params metrics in poll mode")
+	cmd.PersistentFlags().DurationVar(&config.PollValidatorsInterval, "poll-validators-interval", 30*time.Second, "refresh interval of the validators metrics in poll mode")
+	cmd.PersistentFlags().DurationVar(&config.PollWalletsInterval, "poll-wallets-interval", time.Minute, "refresh interval of the wallets metrics in poll mode")
+	cmd.PersistentFlags().DurationVar(&config.PollProposalsInterval, "poll-proposals-interval", 5*time.Minute, "refresh interval of the proposals metrics in poll mode")
+	cmd.PersistentFlags().DurationVar(&config.PollUpgradesInterval, "poll-upgrades-interval", 5*time.Minute, "refresh interval of the upgrades metrics in poll mode")
+
+	cmd.PersistentFlags().BoolVar(&config.BlockWatcher, "block-watcher", false, "follow the blocks of --tendermint-rpc and record which ones the --validators signed")
+	cmd.PersistentFlags().IntVar(&config.BlockWatcherWindow, "block-watcher-window", 100, "number of recent blocks the uptime of the block watcher is computed over")
+	cmd.PersistentFlags().DurationVar(&config.BlockWatcherInterval, "block-watcher-interval", 2*time.Second, "how often the block watcher looks for new blocks")
+}
+
+// PollInterval returns the configured refresh interval of a single mode group.
+func (config *ServiceConfig) PollInterval(group string) time.Duration {
+	switch group {
+	case GroupParams:
+		return config.PollParamsInterval
+	case GroupValidators:
+		return config.PollValidatorsInterval
+	case GroupWallets:
+		return config.PollWalletsInterval
+	case GroupProposals:
+		return config.PollProposalsInterval
+	case GroupUpgrades:
+		return config.PollUpgradesInterval
+	default:
+		return config.PollGeneralInterval
+	}
 }
 
 func (config *ServiceConfig) LogConfig(event *zerolog.Event) *zerolog.Event {
@@ -268,10 +522,25 @@ func (config *ServiceConfig) LogConfig(event *zerolog.Event) *zerolog.Event {
 		Str("--denom", config.Denom).
 		Str("--denom-cofficient", fmt.Sprintf("%f", config.DenomCoefficient)).
 		Str("--denom-exponent", fmt.Sprintf("%d", config.DenomExponent)).
+		Strs("--denom-metadata", config.DenomMetadata).
+		Str("--chains", config.ChainsPath).
 		Str("--listen-address", config.ListenAddress).
-		Str("--node", config.NodeAddress).
+		Str("--web-config-file", config.WebConfigFile).
+		Dur("--http-read-timeout", config.ReadTimeout).
+		Dur("--http-write-timeout", config.WriteTimeout).
+		Dur("--shutdown-timeout", config.ShutdownTimeout).
+		Dur("--ready-max-block-age", config.ReadyMaxBlockAge).
+		Str("--node", strings.Join(config.NodeAddresses, ",")).
+		Bool("--node-tls", config.NodeGrpc.TLS).
+		Strs("--node-header", config.NodeGrpc.HeaderKeys()).
 		Str("--external-node", config.ExternalGrpc).
+		Bool("--external-node-tls", config.ExternalNodeGrpc.TLS).
+		Strs("--external-node-header", config.ExternalNodeGrpc.HeaderKeys()).
 		Str("--log-level", config.LogLevel).
+		Uint64("--limit", config.Limit).
+		Int("--max-pages", config.MaxPages).
+		Dur("--query-timeout", config.QueryTimeout).
+		Dur("--scrape-timeout-offset", config.ScrapeTimeoutOffset).
 		Bool("--single", config.SingleReq).
 		Str("--tendermint-rpc", config.TendermintRPC).
 		Str("--wallets", strings.Join(config.Wallets, ",")).
@@ -281,8 +550,16 @@ func (config *ServiceConfig) LogConfig(event *zerolog.Event) *zerolog.Event {
 		Bool("--params", config.Params).
 		Bool("--upgrades", config.Upgrades).
 		Bool("--price", config.TokenPrice).
+		Strs("--price-providers", config.PriceProviders).
+		Dur("--price-cache-ttl", config.PriceCacheTTL).
+		Str("--cosmos-directory-url", config.CosmosDirectoryURL).
 		Bool("--propv1", config.PropV1).
-		Bool("--votes", config.Votes)
+		Bool("--votes", config.Votes).
+		Bool("--oracle", config.Oracle).
+		Str("--extensions", strings.Join(config.Extensions, ",")).
+		Str("--lcd", config.LCD).
+		Bool("--poll", config.Poll).
+		Bool("--block-watcher", config.BlockWatcher)
 }
 func (config *ServiceConfig) SetIsInitia(flag bool) {
 	config.Initia = flag
@@ -1,6 +1,7 @@
 package exporter
 
 import (
+	"context"
 	"net/http"
 	"sync"
 	"time"
@@ -8,10 +9,73 @@ import (
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
+// names of the metric groups served on /metrics in single mode
+const (
+	GroupGeneral    = "general"
+	GroupParams     = "params"
+	GroupValidators = "validators"
+	GroupWallets    = "wallets"
+	GroupProposals  = "proposals"
+	GroupUpgrades   = "upgrades"
+	GroupExtensions = "extensions"
+)
+
+// singleGroup is one independently collectable part of the single mode /metrics output.
+// The groups valuing coins in USD set prices, they are then given the prices fetched once
+// for the whole run.
+type singleGroup struct {
+	name    string
+	prices  bool
+	enabled func(s *Service) bool
+	collect func(s *Service, ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, reg prometheus.Registerer, prices map[string]float64)
+}
+
+var singleGroups = []singleGroup{
+	{
+		name:    GroupGeneral,
+		prices:  true,
+		enabled: func(_ *Service) bool { return true },
+		collect: (*Service).collectGeneral,
+	},
+	{
+		name:    GroupParams,
+		enabled: func(s *Service) bool { return s.Params },
+		collect: (*Service).collectParams,
+	},
+	{
+		name:    GroupUpgrades,
+		enabled: func(s *Service) bool { return s.Upgrades },
+		collect: (*Service).collectUpgrades,
+	},
+	{
+		name:    GroupValidators,
+		prices:  true,
+		enabled: func(s *Service) bool { return len(s.Validators) > 0 },
+		collect: (*Service).collectValidators,
+	},
+	{
+		name:    GroupWallets,
+		prices:  true,
+		enabled: func(s *Service) bool { return len(s.Wallets) > 0 },
+		collect: (*Service).collectWallets,
+	},
+	{
+		name:    GroupProposals,
+		enabled: func(s *Service) bool { return s.Proposals },
+		collect: (*Service).collectProposals,
+	},
+	{
+		name:    GroupExtensions,
+		enabled: func(s *Service) bool { return len(s.Extensions) > 0 },
+		collect: (*Service).collectExtensions,
+	},
+}
+
 func (s *Service) SingleHandler(w http.ResponseWriter, r *http.Request) {
 	requestStart := time.Now()
 
@@ -20,155 +84,185 @@ func (s *Service) SingleHandler(w http.ResponseWriter, r *http.Request) {
 		Logger()
 
 	registry := prometheus.NewRegistry()
-	generalMetrics := NewGeneralMetrics(registry, s.Config)
-	var validatorMetrics *ValidatorMetrics
-	var paramsMetrics *ParamsMetrics
-	var upgradeMetrics *UpgradeMetrics
-	var walletMetrics *WalletMetrics
 
-	var proposalMetrics *ProposalsMetrics
-	var validatorVotingMetrics *ValidatorVotingMetrics
+	scrapeCtx, cancel := s.ScrapeContext(r)
+	defer cancel()
 
-	if len(s.Validators) > 0 {
-		validatorMetrics = NewValidatorMetrics(registry, s.Config)
-	}
-	if len(s.Wallets) > 0 {
-		walletMetrics = NewWalletMetrics(registry, s.Config)
-	}
-	if s.Params {
-		paramsMetrics = NewParamsMetrics(registry, s.Config)
+	var enabled []singleGroup
+	var prices map[string]float64
+	fetchPrices := false
+	for _, group := range singleGroups {
+		if group.enabled(s) {
+			enabled = append(enabled, group)
+			fetchPrices = fetchPrices || group.prices
+		}
 	}
-	if s.Upgrades {
-		upgradeMetrics = NewUpgradeMetrics(registry, s.Config)
+	// fetched once, for every group
+	if fetchPrices {
+		prices = s.PricesUSD(scrapeCtx, &sublogger)
 	}
 
-	if s.Proposals {
-		proposalMetrics = NewProposalsMetrics(registry, s.Config)
+	var wg sync.WaitGroup
+	var runs []*CollectorRun
+
+	for _, group := range enabled {
+		ctx, run := s.StartCollector(scrapeCtx, group.name)
+		runs = append(runs, run)
+		group.collect(s, ctx, &wg, &sublogger, registry, prices)
 	}
+	wg.Wait()
 
-	if s.Config.Votes && len(s.Validators) > 0 {
-		validatorVotingMetrics = NewValidatorVotingMetrics(registry, s.Config)
+	for _, run := range runs {
+		s.FinishCollector(run)
 	}
 
-	var wg sync.WaitGroup
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+	sublogger.Info().
+		Str("method", "GET").
+		Str("endpoint", "/metrics").
+		Str("type", "regular").
+		Float64("request-time", time.Since(requestStart).Seconds()).
+		Msg("Request processed")
+}
 
-	GetGeneralMetrics(&wg, &sublogger, generalMetrics, s, s.Config)
-	if paramsMetrics != nil {
-		GetParamsMetrics(&wg, &sublogger, paramsMetrics, s, s.Config)
-	}
-	if upgradeMetrics != nil {
-		DoUpgradeMetrics(&wg, &sublogger, upgradeMetrics, s, s.Config)
+func (s *Service) collectGeneral(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, reg prometheus.Registerer, prices map[string]float64) {
+	generalMetrics := NewGeneralMetrics(reg, s.Config)
+	GetGeneralMetrics(ctx, wg, sublogger, generalMetrics, s, s.Config, prices)
+}
+
+func (s *Service) collectParams(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, reg prometheus.Registerer, _ map[string]float64) {
+	paramsMetrics := NewParamsMetrics(reg, s.Config)
+	GetParamsMetrics(ctx, wg, sublogger, paramsMetrics, s, s.Config)
+}
+
+func (s *Service) collectUpgrades(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, reg prometheus.Registerer, _ map[string]float64) {
+	upgradeMetrics := NewUpgradeMetrics(reg, s.Config)
+	DoUpgradeMetrics(ctx, wg, sublogger, upgradeMetrics, s, s.Config)
+}
+
+func (s *Service) collectValidators(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, reg prometheus.Registerer, prices map[string]float64) {
+	validatorMetrics := NewValidatorMetrics(reg, s.Config)
+
+	// every validator below is looked up in the same validator set
+	ctx = WithValidatorSet(ctx)
+
+	valAddresses := make([]sdk.ValAddress, 0, len(s.Validators))
+	for _, validator := range s.Validators {
+		valAddress, err := s.Config.ValAddressFromBech32(validator)
+		if err != nil {
+			sublogger.Error().
+				Str("address", validator).
+				Err(err).
+				Msg("Could not get validator address")
+			continue
+		}
+		valAddresses = append(valAddresses, valAddress)
 	}
-	if len(s.Validators) > 0 {
-		// use 2 groups.
-		// the first group "val_wg" allows us to batch the initial validator call to get the moniker
-		// the 'BasicMetrics' will then add a request to the outer wait 'wg'.
-		// we ensure that all the requests are added by waiting for the 'val_wg' to finish before waiting on the 'wg'
-		var val_wg sync.WaitGroup
-		for _, validator := range s.Validators {
-			valAddress, err := sdk.ValAddressFromBech32(validator)
 
-			if err != nil {
-				sublogger.Error().
-					Str("address", validator).
-					Err(err).
-					Msg("Could not get validator address")
-			} else {
-				val_wg.Add(1)
-				go func(validator string) {
-					defer val_wg.Done()
-					sublogger.Debug().Str("address", validator).Msg("Fetching validator details")
+	// use 2 groups.
+	// the first group "val_wg" allows us to batch the initial validator call to get the moniker
+	// the 'BasicMetrics' will then add a request to the outer wait 'wg'.
+	// we ensure that all the requests are added by waiting for the 'val_wg' to finish before waiting on the 'wg'
 
-					GetValidatorBasicMetrics(&wg, &sublogger, validatorMetrics, s, s.Config, valAddress)
-				}(validator)
+	var val_wg sync.WaitGroup
+	if s.Config.Initia {
+		// initia replaced the staking module, so the validators are looked up by their consensus address
+		s.collectValidatorsByCons(ctx, wg, &val_wg, sublogger, validatorMetrics)
+	} else {
+		for _, valAddress := range valAddresses {
+			val_wg.Add(1)
+			go func(valAddress sdk.ValAddress) {
+				defer val_wg.Done()
+				sublogger.Debug().Str("address", s.Config.ValAddressString(valAddress)).Msg("Fetching validator details")
 
-			}
+				GetValidatorBasicMetrics(ctx, wg, sublogger, validatorMetrics, s, s.Config, prices, valAddress)
+			}(valAddress)
 		}
-		val_wg.Wait()
 	}
-	if s.Config.Votes && len(s.Validators) > 0 {
-		// use 2 groups.
-		// the first group "prop_wg" allows us to batch the call to get the active props
-		// the 'BasicMetrics' will then add a request to the outer wait 'wg'.
-		// we ensure that all the requests are added by waiting for the 'val_wg' to finish before waiting on the 'wg'
-		var prop_wg sync.WaitGroup
-		prop_wg.Add(1)
-		var activeProps []uint64
-
-		go func() {
-			defer prop_wg.Done()
-			var err error
-			if s.Config.PropV1 {
-				activeProps, err = s.GetActiveProposalsV1(&sublogger)
-				if err != nil {
-					sublogger.Error().
-						Err(err).
-						Msg("Could not get active proposals V1 (general)")
-				}
-			} else {
-				activeProps, err = s.GetActiveProposals(&sublogger)
-				if err != nil {
-					sublogger.Error().
-						Err(err).
-						Msg("Could not get active proposals (general)")
-				}
-			}
-		}()
+	val_wg.Wait()
 
-		prop_wg.Wait()
+	if !s.Config.Votes {
+		return
+	}
+
+	validatorVotingMetrics := NewValidatorVotingMetrics(reg, s.Config)
 
-		for _, validator := range s.Validators {
-			valAddress, err := sdk.ValAddressFromBech32(validator)
+	// use 2 groups.
+	// the first group "prop_wg" allows us to batch the call to get the active props
+	// the 'BasicMetrics' will then add a request to the outer wait 'wg'.
+	// we ensure that all the requests are added by waiting for the 'val_wg' to finish before waiting on the 'wg'
+	var prop_wg sync.WaitGroup
+	prop_wg.Add(1)
+	var activeProps []uint64
+
+	go func() {
+		defer prop_wg.Done()
+		var err error
+		if s.Config.PropV1 {
+			activeProps, err = s.GetActiveProposalsV1(ctx, sublogger)
 			if err != nil {
 				sublogger.Error().
-					Str("address", validator).
 					Err(err).
-					Msg("Could not get validator address")
-			} else {
-				var accAddress sdk.AccAddress
-				err := accAddress.Unmarshal(valAddress.Bytes())
-				if err != nil {
-					sublogger.Error().
-						Str("address", validator).
-						Err(err).
-						Msg("Could not get acc address")
-				}
-				for _, propId := range activeProps {
-					GetProposalsVoteMetrics(&wg, &sublogger, validatorVotingMetrics, s, s.Config, propId, valAddress, accAddress)
-					/*
-						sublogger.Debug().
-							Str("Validator", valAddress.String()).
-							Str("Wallet", accAddress.String()).
-							Uint64("Prop", propId).Msg("Get Vote")*/
-				}
+					Msg("Could not get active proposals V1 (general)")
 			}
-		}
-	}
-
-	if len(s.Wallets) > 0 {
-		for _, wallet := range s.Wallets {
-			accAddress, err := sdk.AccAddressFromBech32(wallet)
+		} else {
+			activeProps, err = s.GetActiveProposals(ctx, sublogger)
 			if err != nil {
 				sublogger.Error().
-					Str("address", wallet).
 					Err(err).
-					Msg("Could not get wallet address")
-			} else {
-				GetWalletMetrics(&wg, &sublogger, walletMetrics, s, s.Config, accAddress, false)
+					Msg("Could not get active proposals (general)")
 			}
 		}
+	}()
+
+	prop_wg.Wait()
+
+	for _, valAddress := range valAddresses {
+		// the operator votes with the account of the same key
+		accAddress := sdk.AccAddress(valAddress)
+		for _, propId := range activeProps {
+			GetProposalsVoteMetrics(ctx, wg, sublogger, validatorVotingMetrics, s, s.Config, propId, valAddress, accAddress)
+		}
 	}
-	if s.Proposals {
-		GetProposalsMetrics(&wg, &sublogger, proposalMetrics, s, s.Config, true)
+}
+
+// collectValidatorsByCons pairs every --validators entry with the --validatorcons entry at the same position.
+func (s *Service) collectValidatorsByCons(ctx context.Context, wg *sync.WaitGroup, val_wg *sync.WaitGroup, sublogger *zerolog.Logger, validatorMetrics *ValidatorMetrics) {
+	for index, valConsAddress := range s.ValidatorCons {
+		if index >= len(s.Validators) {
+			sublogger.Error().
+				Str("consaddress", valConsAddress).
+				Msg("No validator matches the validatorcons entry")
+			continue
+		}
+		val_wg.Add(1)
+		go func(validator string, valConsAddress string) {
+			defer val_wg.Done()
+			sublogger.Debug().Str("consaddress", valConsAddress).Msg("Fetching validator details")
+
+			GetValidatorBasicMetricsTM(ctx, wg, sublogger, validatorMetrics, s, s.Config, "n/a", validator, valConsAddress)
+		}(s.Validators[index], valConsAddress)
 	}
-	wg.Wait()
+}
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
-	h.ServeHTTP(w, r)
-	sublogger.Info().
-		Str("method", "GET").
-		Str("endpoint", "/metrics").
-		Str("type", "regular").
-		Float64("request-time", time.Since(requestStart).Seconds()).
-		Msg("Request processed")
+func (s *Service) collectWallets(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, reg prometheus.Registerer, prices map[string]float64) {
+	walletMetrics := NewWalletMetrics(reg, s.Config)
+
+	for _, wallet := range s.Wallets {
+		accAddress, err := s.Config.AccAddressFromBech32(wallet)
+		if err != nil {
+			sublogger.Error().
+				Str("address", wallet).
+				Err(err).
+				Msg("Could not get wallet address")
+		} else {
+			GetWalletMetrics(ctx, wg, sublogger, walletMetrics, s, s.Config, prices, accAddress, false)
+		}
+	}
+}
+
+func (s *Service) collectProposals(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, reg prometheus.Registerer, _ map[string]float64) {
+	proposalMetrics := NewProposalsMetrics(reg, s.Config)
+	GetProposalsMetrics(ctx, wg, sublogger, proposalMetrics, s, s.Config, true)
 }
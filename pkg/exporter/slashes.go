@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sync"
+
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+)
+
+// SlashHeights caches the height of the last slash of every validator. Finding it takes a
+// few queries, so it is only looked up again when the validator gets slashed again.
+type SlashHeights struct {
+	mu      sync.Mutex
+	heights map[string]slashHeight
+}
+
+// slashHeight is the height of the last of the slashes events of a validator.
+type slashHeight struct {
+	slashes int
+	height  int64
+}
+
+func NewSlashHeights() *SlashHeights {
+	return &SlashHeights{heights: make(map[string]slashHeight)}
+}
+
+func (h *SlashHeights) get(operator string, slashes int) (int64, bool) {
+	if h == nil {
+		return 0, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cached, ok := h.heights[operator]
+	return cached.height, ok && cached.slashes == slashes
+}
+
+func (h *SlashHeights) set(operator string, slashes int, height int64) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.heights[operator] = slashHeight{slashes: slashes, height: height}
+}
+
+// LastSlashHeight returns the height of the last of the slashes events of the validator.
+// The events do not carry their height, which is only the start of their key in the store:
+// starting the pagination at a height lists the events at or above it, so the height is
+// bisected between 0 and the latest block, in about log2(latest block) queries.
+func (s *Service) LastSlashHeight(ctx context.Context, operator string, slashes int) (int64, error) {
+	if height, ok := s.SlashHeights.get(operator, slashes); ok {
+		return height, nil
+	}
+
+	latest, err := s.GetLatestBlock(ctx, s.GrpcConn)
+	if err != nil {
+		return 0, err
+	}
+
+	distributionClient := distributiontypes.NewQueryClient(s.GrpcConn)
+	slashedSince := func(height int64) (bool, error) {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(height))
+		res, err := distributionClient.ValidatorSlashes(
+			ctx,
+			&distributiontypes.QueryValidatorSlashesRequest{
+				ValidatorAddress: operator,
+				EndingHeight:     math.MaxUint64,
+				Pagination:       &querytypes.PageRequest{Key: key, Limit: 1},
+			},
+		)
+		return len(res.GetSlashes()) > 0, err
+	}
+
+	// there is an event at or above low, there is none above high
+	low, high := int64(0), int64(latest)
+	for low < high {
+		middle := low + (high-low+1)/2
+		slashed, err := slashedSince(middle)
+		if err != nil {
+			return 0, err
+		}
+		if slashed {
+			low = middle
+		} else {
+			high = middle - 1
+		}
+	}
+
+	s.SlashHeights.set(operator, slashes, low)
+	return low, nil
+}
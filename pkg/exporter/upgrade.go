@@ -34,7 +34,7 @@ func NewUpgradeMetrics(reg prometheus.Registerer, config *ServiceConfig) *Upgrad
 	return m
 }
 
-func DoUpgradeMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *UpgradeMetrics, s *Service, config *ServiceConfig) {
+func DoUpgradeMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *UpgradeMetrics, s *Service, config *ServiceConfig) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -42,7 +42,7 @@ func DoUpgradeMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *Up
 
 		upgradeClient := upgradetypes.NewQueryClient(s.GrpcConn)
 		upgradeRes, err := upgradeClient.CurrentPlan(
-			context.Background(),
+			ctx,
 			&upgradetypes.QueryCurrentPlanRequest{},
 		)
 		if err != nil {
@@ -66,7 +66,9 @@ func DoUpgradeMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *Up
 			return
 		}
 
-		cs, err := NewChainStatus(config)
+		queryStart = time.Now()
+		cs, err := NewChainStatus(ctx, config)
+		s.ObserveQuery(ctx, "rpc.Status", queryStart, err)
 		if err != nil {
 			sublogger.Error().
 				Err(err).
@@ -113,12 +115,17 @@ func (s *Service) UpgradeHandler(w http.ResponseWriter, r *http.Request) {
 	registry := prometheus.NewRegistry()
 	upgradeMetrics := NewUpgradeMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "upgrades")
 	var wg sync.WaitGroup
-	DoUpgradeMetrics(&wg, &sublogger, upgradeMetrics, s, s.Config)
+	DoUpgradeMetrics(ctx, &wg, &sublogger, upgradeMetrics, s, s.Config)
 
 	wg.Wait()
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	s.FinishCollector(run)
+
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
@@ -3,6 +3,8 @@ package exporter
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strconv"
 	"time"
 
 	tmrpc "github.com/cometbft/cometbft/rpc/client/http"
@@ -13,13 +15,13 @@ type ChainStatus struct {
 	status *coretypes.ResultStatus
 }
 
-func NewChainStatus(config *ServiceConfig) (ChainStatus, error) {
+func NewChainStatus(ctx context.Context, config *ServiceConfig) (ChainStatus, error) {
 	client, err := tmrpc.New(config.TendermintRPC, "/websocket")
 	if err != nil {
 		return ChainStatus{}, err
 	}
 
-	status, err := client.Status(context.Background())
+	status, err := client.Status(ctx)
 	if err != nil {
 		return ChainStatus{}, err
 	}
@@ -61,3 +63,44 @@ func (cs ChainStatus) EstimateBlockTime(totalHeight int64) (time.Time, error) {
 
 	return estimated, nil
 }
+
+// ScrapeContext returns the context the collectors of a request should use. It is cancelled when the
+// client goes away, and expires just before the timeout Prometheus announces in its scrape header,
+// so whatever was collected by then is still returned.
+func (s *Service) ScrapeContext(r *http.Request) (context.Context, context.CancelFunc) {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if header == "" {
+		return context.WithCancel(r.Context())
+	}
+
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil {
+		s.Log.Warn().
+			Str("header", header).
+			Err(err).
+			Msg("Could not parse scrape timeout header")
+		return context.WithCancel(r.Context())
+	}
+
+	timeout := time.Duration(seconds*float64(time.Second)) - s.Config.ScrapeTimeoutOffset
+	if timeout <= 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// unixSeconds returns t as a unix timestamp, 0 for the zero time, which is also how the chain
+// stores "never".
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() || t.Unix() <= 0 {
+		return 0
+	}
+	return float64(t.Unix())
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
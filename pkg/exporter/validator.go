@@ -2,9 +2,8 @@ package exporter
 
 import (
 	"context"
-	"encoding/hex"
+	"math"
 	"net/http"
-	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -14,28 +13,61 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	//codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	//k	crytpocode "github.com/cosmos/cosmos-sdk/crypto/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	querytypes "github.com/cosmos/cosmos-sdk/types/query"
 	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
-	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 )
 
 type ValidatorMetrics struct {
 	tokensGauge          *prometheus.GaugeVec
+	tokensUSDGauge       *prometheus.GaugeVec
 	delegatorSharesGauge *prometheus.GaugeVec
 	commissionRateGauge  *prometheus.GaugeVec
 	statusGauge          *prometheus.GaugeVec
 	jailedGauge          *prometheus.GaugeVec
 	missedBlocksGauge    *prometheus.GaugeVec
+
+	commissionMaxRateGauge       *prometheus.GaugeVec
+	commissionMaxChangeRateGauge *prometheus.GaugeVec
+	commissionUpdateTimeGauge    *prometheus.GaugeVec
+	minSelfDelegationGauge       *prometheus.GaugeVec
+
+	selfDelegationGauge       *prometheus.GaugeVec
+	selfDelegationMarginGauge *prometheus.GaugeVec
+
+	infoGauge *prometheus.GaugeVec
+
+	slashesGauge           *prometheus.GaugeVec
+	lastSlashFractionGauge *prometheus.GaugeVec
+	lastSlashHeightGauge   *prometheus.GaugeVec
+	slashedFractionGauge   *prometheus.GaugeVec
+
+	missedBlocksRemainingGauge  *prometheus.GaugeVec
+	missedBlocksWindowUsedGauge *prometheus.GaugeVec
+	secondsUntilJailGauge       *prometheus.GaugeVec
+
+	tombstonedGauge  *prometheus.GaugeVec
+	jailedUntilGauge *prometheus.GaugeVec
+	startHeightGauge *prometheus.GaugeVec
+	indexOffsetGauge *prometheus.GaugeVec
+
+	votingPowerShareGauge           *prometheus.GaugeVec
+	cumulativeVotingPowerShareGauge *prometheus.GaugeVec
+	tokensAboveActiveSetGauge       *prometheus.GaugeVec
+	tokensToNextRankGauge           *prometheus.GaugeVec
 }
 type ValidatorExtendedMetrics struct {
 	delegationsGauge   *prometheus.GaugeVec
 	commissionGauge    *prometheus.GaugeVec
+	commissionUSDGauge *prometheus.GaugeVec
 	rewardsGauge       *prometheus.GaugeVec
+	rewardsUSDGauge    *prometheus.GaugeVec
 	unbondingsGauge    *prometheus.GaugeVec
 	redelegationsGauge *prometheus.GaugeVec
 
@@ -53,6 +85,14 @@ func NewValidatorMetrics(reg prometheus.Registerer, config *ServiceConfig) *Vali
 			},
 			[]string{"address", "moniker", "denom"},
 		),
+		tokensUSDGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_tokens_usd",
+				Help:        "Tokens of the Cosmos-based blockchain validator in USD",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
 
 		delegatorSharesGauge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -71,6 +111,96 @@ func NewValidatorMetrics(reg prometheus.Registerer, config *ServiceConfig) *Vali
 			},
 			[]string{"address", "moniker"},
 		),
+		commissionMaxRateGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_commission_max_rate",
+				Help:        "Maximum commission rate the Cosmos-based blockchain validator can ever charge",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		commissionMaxChangeRateGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_commission_max_change_rate",
+				Help:        "Maximum daily increase of the commission rate of the Cosmos-based blockchain validator",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		commissionUpdateTimeGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_commission_update_time",
+				Help:        "Unix timestamp of the last commission change of the Cosmos-based blockchain validator",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		minSelfDelegationGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_min_self_delegation",
+				Help:        "Self declared minimum self delegation of the Cosmos-based blockchain validator",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker", "denom"},
+		),
+		selfDelegationGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_self_delegation",
+				Help:        "Tokens delegated to the Cosmos-based blockchain validator by its operator",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker", "denom"},
+		),
+		selfDelegationMarginGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_self_delegation_margin",
+				Help:        "Self delegation of the Cosmos-based blockchain validator above its minimum self delegation, it is jailed when negative",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker", "denom"},
+		),
+
+		infoGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_info",
+				Help:        "Description and consensus public key of the Cosmos-based blockchain validator, always 1",
+				ConstLabels: config.ConstLabels,
+			},
+			append([]string{"address"}, validatorInfoFields...),
+		),
+
+		slashesGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_slashes",
+				Help:        "Number of times the Cosmos-based blockchain validator was slashed",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		lastSlashFractionGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_last_slash_fraction",
+				Help:        "Fraction of the stake of the Cosmos-based blockchain validator burnt by its last slash",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		lastSlashHeightGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_last_slash_height",
+				Help:        "Height the Cosmos-based blockchain validator was last slashed at",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		slashedFractionGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_slashed_fraction",
+				Help:        "Fraction of the stake of the Cosmos-based blockchain validator burnt by all its slashes",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
 
 		statusGauge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -97,14 +227,129 @@ func NewValidatorMetrics(reg prometheus.Registerer, config *ServiceConfig) *Vali
 			},
 			[]string{"address", "moniker"},
 		),
+		missedBlocksRemainingGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_missed_blocks_remaining",
+				Help:        "Blocks the Cosmos-based blockchain validator can still miss in the signing window before being jailed",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		missedBlocksWindowUsedGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_missed_blocks_window_used_percent",
+				Help:        "Missed blocks of the Cosmos-based blockchain validator, in percent of the signing window",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		secondsUntilJailGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_seconds_until_jail",
+				Help:        "Estimated seconds until the Cosmos-based blockchain validator is jailed if it misses every block",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+
+		tombstonedGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_tombstoned",
+				Help:        "1 if the Cosmos-based blockchain validator is tombstoned, 0 if no",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		jailedUntilGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_jailed_until",
+				Help:        "Unix timestamp the Cosmos-based blockchain validator can be unjailed at, 0 if it never was jailed",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		startHeightGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_start_height",
+				Help:        "Height the Cosmos-based blockchain validator started signing at",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		indexOffsetGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_index_offset",
+				Help:        "Index of the Cosmos-based blockchain validator in its signing window",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+
+		votingPowerShareGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_voting_power_share",
+				Help:        "Share of the bonded tokens held by the Cosmos-based blockchain validator",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		cumulativeVotingPowerShareGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_cumulative_voting_power_share",
+				Help:        "Share of the bonded tokens held by the Cosmos-based blockchain validator and the validators ranked above it",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker"},
+		),
+		tokensAboveActiveSetGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_tokens_above_active_set",
+				Help:        "Tokens of the Cosmos-based blockchain validator above the last validator of the active set, negative if it is out of the set",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker", "denom"},
+		),
+		tokensToNextRankGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_tokens_to_next_rank",
+				Help:        "Tokens the Cosmos-based blockchain validator needs to overtake the validator ranked above it",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "moniker", "denom"},
+		),
 	}
 
 	reg.MustRegister(m.tokensGauge)
+	if config.TokenPrice {
+		reg.MustRegister(m.tokensUSDGauge)
+	}
 	reg.MustRegister(m.delegatorSharesGauge)
 	reg.MustRegister(m.commissionRateGauge)
+	reg.MustRegister(m.commissionMaxRateGauge)
+	reg.MustRegister(m.commissionMaxChangeRateGauge)
+	reg.MustRegister(m.commissionUpdateTimeGauge)
+	reg.MustRegister(m.minSelfDelegationGauge)
+	reg.MustRegister(m.selfDelegationGauge)
+	reg.MustRegister(m.selfDelegationMarginGauge)
+	reg.MustRegister(m.infoGauge)
+	reg.MustRegister(m.slashesGauge)
+	reg.MustRegister(m.lastSlashFractionGauge)
+	reg.MustRegister(m.lastSlashHeightGauge)
+	reg.MustRegister(m.slashedFractionGauge)
 	reg.MustRegister(m.statusGauge)
 	reg.MustRegister(m.jailedGauge)
 	reg.MustRegister(m.missedBlocksGauge)
+	reg.MustRegister(m.missedBlocksRemainingGauge)
+	reg.MustRegister(m.missedBlocksWindowUsedGauge)
+	reg.MustRegister(m.secondsUntilJailGauge)
+	reg.MustRegister(m.tombstonedGauge)
+	reg.MustRegister(m.jailedUntilGauge)
+	reg.MustRegister(m.startHeightGauge)
+	reg.MustRegister(m.indexOffsetGauge)
+	reg.MustRegister(m.votingPowerShareGauge)
+	reg.MustRegister(m.cumulativeVotingPowerShareGauge)
+	reg.MustRegister(m.tokensAboveActiveSetGauge)
+	reg.MustRegister(m.tokensToNextRankGauge)
 
 	return m
 }
@@ -126,6 +371,14 @@ func NewValidatorExtendedMetrics(reg prometheus.Registerer, config *ServiceConfi
 				Help:        "Commission of the Cosmos-based blockchain validator",
 				ConstLabels: config.ConstLabels,
 			},
+			[]string{"address", "moniker", "denom", "display_denom"},
+		),
+		commissionUSDGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_commission_usd",
+				Help:        "Commission of the Cosmos-based blockchain validator in USD",
+				ConstLabels: config.ConstLabels,
+			},
 			[]string{"address", "moniker", "denom"},
 		),
 		rewardsGauge: prometheus.NewGaugeVec(
@@ -134,6 +387,14 @@ func NewValidatorExtendedMetrics(reg prometheus.Registerer, config *ServiceConfi
 				Help:        "Rewards of the Cosmos-based blockchain validator",
 				ConstLabels: config.ConstLabels,
 			},
+			[]string{"address", "moniker", "denom", "display_denom"},
+		),
+		rewardsUSDGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_validator_rewards_usd",
+				Help:        "Rewards of the Cosmos-based blockchain validator in USD",
+				ConstLabels: config.ConstLabels,
+			},
 			[]string{"address", "moniker", "denom"},
 		),
 
@@ -178,6 +439,10 @@ func NewValidatorExtendedMetrics(reg prometheus.Registerer, config *ServiceConfi
 
 	reg.MustRegister(m.commissionGauge)
 	reg.MustRegister(m.rewardsGauge)
+	if config.TokenPrice {
+		reg.MustRegister(m.commissionUSDGauge)
+		reg.MustRegister(m.rewardsUSDGauge)
+	}
 	reg.MustRegister(m.unbondingsGauge)
 	reg.MustRegister(m.redelegationsGauge)
 
@@ -187,173 +452,356 @@ func NewValidatorExtendedMetrics(reg prometheus.Registerer, config *ServiceConfi
 	return m
 }
 
-func GetValidatorBasicMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorMetrics, s *Service, config *ServiceConfig, validatorAddress sdk.ValAddress) *stakingtypes.QueryValidatorResponse {
+func GetValidatorBasicMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorMetrics, s *Service, config *ServiceConfig, prices map[string]float64, validatorAddress sdk.ValAddress) *stakingtypes.Validator {
+	operatorAddress := config.ValAddressString(validatorAddress)
+
 	// doing this not in goroutine as we'll need the moniker value later
-	sublogger.Debug().
-		Str("address", validatorAddress.String()).
-		Msg("Started querying validator")
-	validatorQueryStart := time.Now()
-
-	stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-	validator, err := stakingClient.Validator(
-		context.Background(),
-		&stakingtypes.QueryValidatorRequest{ValidatorAddr: validatorAddress.String()},
-	)
-	if err != nil {
-		sublogger.Error().
-			Str("address", validatorAddress.String()).
-			Err(err).
-			Msg("Could not get validator")
-		return nil
-	}
+	validatorSet := s.ValidatorSet(ctx, sublogger)
+	validator, found := validatorSet.Validator(operatorAddress)
+	if !found {
+		// the set is incomplete when its query failed or got capped by --max-pages
+		sublogger.Debug().
+			Str("address", operatorAddress).
+			Msg("Started querying validator")
+		validatorQueryStart := time.Now()
+
+		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
+		validatorRes, err := stakingClient.Validator(
+			ctx,
+			&stakingtypes.QueryValidatorRequest{ValidatorAddr: operatorAddress},
+		)
+		if err != nil {
+			sublogger.Error().
+				Str("address", operatorAddress).
+				Err(err).
+				Msg("Could not get validator")
+			return nil
+		}
 
-	sublogger.Debug().
-		Str("address", validatorAddress.String()).
-		Float64("request-time", time.Since(validatorQueryStart).Seconds()).
-		Msg("Finished querying validator")
+		sublogger.Debug().
+			Str("address", operatorAddress).
+			Float64("request-time", time.Since(validatorQueryStart).Seconds()).
+			Msg("Finished querying validator")
+		validator = validatorRes.Validator
+	}
 
-	if value, err := strconv.ParseFloat(validator.Validator.Tokens.String(), 64); err != nil {
+	if value, err := strconv.ParseFloat(validator.Tokens.String(), 64); err != nil {
 		sublogger.Error().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Err(err).
 			Msg("Could not parse validator tokens")
 	} else {
 		metrics.tokensGauge.With(prometheus.Labels{
-			"address": validator.Validator.OperatorAddress,
-			"moniker": validator.Validator.Description.Moniker,
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
 			"denom":   config.Denom,
 		}).Set(value / config.DenomCoefficient)
+		setUSD(metrics.tokensUSDGauge, prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+		}, prices, s.Denoms.Base(config.Denom), value/config.DenomCoefficient)
+	}
+
+	if found {
+		getValidatorStakePosition(metrics, validatorSet, validator, config)
 	}
 
 	// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
-	if value, err := strconv.ParseFloat(validator.Validator.DelegatorShares.String(), 64); err != nil {
+	if value, err := strconv.ParseFloat(validator.DelegatorShares.String(), 64); err != nil {
 		sublogger.Error().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Err(err).
 			Msg("Could not parse delegator shares")
 	} else {
 		metrics.delegatorSharesGauge.With(prometheus.Labels{
-			"address": validator.Validator.OperatorAddress,
-			"moniker": validator.Validator.Description.Moniker,
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
 			"denom":   config.Denom,
 		}).Set(value / config.DenomCoefficient)
 	}
 
 	// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
-	if rate, err := strconv.ParseFloat(validator.Validator.Commission.CommissionRates.Rate.String(), 64); err != nil {
+	if rate, err := strconv.ParseFloat(validator.Commission.CommissionRates.Rate.String(), 64); err != nil {
 		sublogger.Error().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Err(err).
 			Msg("Could not parse commission rate")
 	} else {
 		metrics.commissionRateGauge.With(prometheus.Labels{
-			"address": validator.Validator.OperatorAddress,
-			"moniker": validator.Validator.Description.Moniker,
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
 		}).Set(rate)
 	}
 
+	getValidatorCommission(metrics, validator, config, sublogger)
+	s.Commissions.Observe(validator)
+	GetValidatorSelfDelegationMetrics(ctx, wg, sublogger, metrics, s, config, validator)
+	GetValidatorSlashesMetrics(ctx, wg, sublogger, metrics, s, config, validator)
+
+	setValidatorInfo(metrics, s, validator, sublogger)
+
 	metrics.statusGauge.With(prometheus.Labels{
-		"address": validator.Validator.OperatorAddress,
-		"moniker": validator.Validator.Description.Moniker,
-	}).Set(float64(validator.Validator.Status))
+		"address": validator.OperatorAddress,
+		"moniker": validator.Description.Moniker,
+	}).Set(float64(validator.Status))
 
 	// golang doesn't have a ternary operator, so we have to stick with this ugly solution
 	var jailed float64
 
-	if validator.Validator.Jailed {
+	if validator.Jailed {
 		jailed = 1
 	} else {
 		jailed = 0
 	}
 	metrics.jailedGauge.With(prometheus.Labels{
-		"address": validator.Validator.OperatorAddress,
-		"moniker": validator.Validator.Description.Moniker,
+		"address": validator.OperatorAddress,
+		"moniker": validator.Description.Moniker,
 	}).Set(jailed)
 
-	pubKey, err := validator.Validator.GetConsAddr()
-	if err != nil {
+	valcons := validatorSet.ConsAddress(operatorAddress)
+	if valcons == nil {
+		pubKey, err := validator.GetConsAddr()
+		if err != nil {
+			sublogger.Error().
+				Str("address", operatorAddress).
+				Err(err).
+				Msg("Could not get validator pubkey")
+		}
+		valcons = pubKey
+	}
+	GetValidatorBasicMetricsTM(ctx, wg, sublogger, metrics, s, config, validator.Description.GetMoniker(), operatorAddress, config.ConsAddressString(valcons))
+
+	return &validator
+}
+
+// getValidatorCommission exports the commission bounds the validator set when it was created,
+// when it last changed its commission, and its minimum self delegation.
+func getValidatorCommission(metrics *ValidatorMetrics, validator stakingtypes.Validator, config *ServiceConfig, sublogger *zerolog.Logger) {
+	labels := prometheus.Labels{
+		"address": validator.OperatorAddress,
+		"moniker": validator.Description.Moniker,
+	}
+
+	// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+	if value, err := strconv.ParseFloat(validator.Commission.CommissionRates.MaxRate.String(), 64); err != nil {
+		sublogger.Error().
+			Str("address", validator.OperatorAddress).
+			Err(err).
+			Msg("Could not parse commission max rate")
+	} else {
+		metrics.commissionMaxRateGauge.With(labels).Set(value)
+	}
+
+	if value, err := strconv.ParseFloat(validator.Commission.CommissionRates.MaxChangeRate.String(), 64); err != nil {
 		sublogger.Error().
-			Str("address", validatorAddress.String()).
+			Str("address", validator.OperatorAddress).
 			Err(err).
-			Msg("Could not get validator pubkey")
+			Msg("Could not parse commission max change rate")
+	} else {
+		metrics.commissionMaxChangeRateGauge.With(labels).Set(value)
 	}
-	valcons, err := sdk.ConsAddressFromHex(hex.EncodeToString(pubKey))
+
+	metrics.commissionUpdateTimeGauge.With(labels).Set(unixSeconds(validator.Commission.UpdateTime))
+
+	if value, err := strconv.ParseFloat(validator.MinSelfDelegation.String(), 64); err != nil {
+		sublogger.Error().
+			Str("address", validator.OperatorAddress).
+			Err(err).
+			Msg("Could not parse validator min self delegation")
+	} else {
+		metrics.minSelfDelegationGauge.With(prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+			"denom":   config.Denom,
+		}).Set(value / config.DenomCoefficient)
+	}
+}
+
+// GetValidatorSelfDelegationMetrics queries the delegation of the operator's account to its
+// validator, which has to stay above the minimum self delegation.
+func GetValidatorSelfDelegationMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorMetrics, s *Service, config *ServiceConfig, validator stakingtypes.Validator) {
+	valAddress, err := config.ValAddressFromBech32(validator.OperatorAddress)
 	if err != nil {
 		sublogger.Error().
-			Str("address", validatorAddress.String()).
+			Str("address", validator.OperatorAddress).
 			Err(err).
-			Msg("Could not get validatorcons from ConsAddressFromHex")
+			Msg("Could not parse validator address")
+		return
 	}
-	GetValidatorBasicMetricsTM(wg, sublogger, metrics, s, config, validatorAddress.String(), validator.Validator.Description.GetMoniker(), valcons.String())
-	/*
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+	// the operator delegates with the account of the same key
+	delegatorAddress := config.AccAddressString(sdk.AccAddress(valAddress))
 
-			sublogger.Debug().
-				Str("address", validatorAddress.String()).
-				Msg("Started querying validator signing info")
-			queryStart := time.Now()
-			interfaceRegistry := codectypes.NewInterfaceRegistry()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
 
-			crytpocode.RegisterInterfaces(interfaceRegistry)
+		sublogger.Debug().
+			Str("address", validator.OperatorAddress).
+			Str("delegator", delegatorAddress).
+			Msg("Started querying validator self delegation")
+		queryStart := time.Now()
 
-			err := validator.Validator.UnpackInterfaces(interfaceRegistry) // Unpack interfaces, to populate the Anys' cached values
-			if err != nil {
-				sublogger.Error().
-					Str("address", validatorAddress.String()).
-					Err(err).
-					Msg("Could not get unpack validator inferfaces")
-			}
+		selfDelegation := float64(0)
+		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
+		delegationRes, err := stakingClient.Delegation(
+			ctx,
+			&stakingtypes.QueryDelegationRequest{
+				DelegatorAddr: delegatorAddress,
+				ValidatorAddr: validator.OperatorAddress,
+			},
+		)
+		if status.Code(err) == codes.NotFound {
+			// the operator unbonded everything
+			err = nil
+		} else if err == nil {
+			selfDelegation, err = strconv.ParseFloat(delegationRes.DelegationResponse.Balance.Amount.String(), 64)
+		}
+		if err != nil {
+			sublogger.Error().
+				Str("address", validator.OperatorAddress).
+				Err(err).
+				Msg("Could not get validator self delegation")
+			return
+		}
 
-			pubKey, err := validator.Validator.GetConsAddr()
-			if err != nil {
-				sublogger.Error().
-					Str("address", validatorAddress.String()).
-					Err(err).
-					Msg("Could not get validator pubkey")
-			}
-			valcons, err := sdk.ConsAddressFromHex(hex.EncodeToString(pubKey))
-			if err != nil {
-				sublogger.Error().
-					Str("address", validatorAddress.String()).
-					Err(err).
-					Msg("Could not get validatorcons from ConsAddressFromHex")
-			}
+		sublogger.Debug().
+			Str("address", validator.OperatorAddress).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validator self delegation")
 
-			slashingClient := slashingtypes.NewQueryClient(s.GrpcConn)
-			slashingRes, err := slashingClient.SigningInfo(
-				context.Background(),
-				&slashingtypes.QuerySigningInfoRequest{ConsAddress: valcons.String()},
-			)
+		labels := prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+			"denom":   config.Denom,
+		}
+		metrics.selfDelegationGauge.With(labels).Set(selfDelegation / config.DenomCoefficient)
+
+		// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+		if minSelfDelegation, err := strconv.ParseFloat(validator.MinSelfDelegation.String(), 64); err != nil {
+			sublogger.Error().
+				Str("address", validator.OperatorAddress).
+				Err(err).
+				Msg("Could not parse validator min self delegation")
+		} else {
+			metrics.selfDelegationMarginGauge.With(labels).Set((selfDelegation - minSelfDelegation) / config.DenomCoefficient)
+		}
+	}()
+}
+
+// GetValidatorSlashesMetrics queries the slash events of the validator. The query does not
+// return the heights of the events, the height of the last one is looked up on its own.
+func GetValidatorSlashesMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorMetrics, s *Service, config *ServiceConfig, validator stakingtypes.Validator) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		sublogger.Debug().
+			Str("address", validator.OperatorAddress).
+			Msg("Started querying validator slashes")
+		queryStart := time.Now()
+
+		distributionClient := distributiontypes.NewQueryClient(s.GrpcConn)
+		slashes, err := Paginate(ctx, s, "distribution.ValidatorSlashes",
+			func(ctx context.Context, page *querytypes.PageRequest) ([]distributiontypes.ValidatorSlashEvent, *querytypes.PageResponse, error) {
+				res, err := distributionClient.ValidatorSlashes(
+					ctx,
+					&distributiontypes.QueryValidatorSlashesRequest{
+						ValidatorAddress: validator.OperatorAddress,
+						// every event, the chain rejects an ending height below the starting one
+						EndingHeight: math.MaxUint64,
+						Pagination:   page,
+					},
+				)
+				return res.GetSlashes(), res.GetPagination(), err
+			})
+		if err != nil {
+			sublogger.Error().
+				Str("address", validator.OperatorAddress).
+				Err(err).
+				Msg("Could not get validator slashes")
+			return
+		}
+
+		sublogger.Debug().
+			Str("address", validator.OperatorAddress).
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Int("slashes", len(slashes)).
+			Msg("Finished querying validator slashes")
+
+		labels := prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+		}
+		// parsed first, so that a scrape shows all the slash metrics or none of them
+		fractions := make([]float64, len(slashes))
+		for index, slash := range slashes {
+			// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
+			fraction, err := strconv.ParseFloat(slash.Fraction.String(), 64)
 			if err != nil {
 				sublogger.Error().
-					Str("address", validatorAddress.String()).
+					Str("address", validator.OperatorAddress).
 					Err(err).
-					Msg("Could not get validator signing info")
+					Msg("Could not parse slash fraction")
 				return
 			}
+			fractions[index] = fraction
+		}
+
+		metrics.slashesGauge.With(labels).Set(float64(len(slashes)))
+
+		// every slash burns a fraction of what the previous ones left
+		remaining := float64(1)
+		for _, fraction := range fractions {
+			remaining *= 1 - fraction
+		}
+		metrics.slashedFractionGauge.With(labels).Set(1 - remaining)
+		if len(slashes) == 0 {
+			return
+		}
 
-			sublogger.Debug().
-				Str("address", validatorAddress.String()).
-				Float64("request-time", time.Since(queryStart).Seconds()).
-				Msg("Finished querying validator signing info")
-
-			sublogger.Debug().
-				Str("address", validatorAddress.String()).
-				Int64("missedBlocks", slashingRes.ValSigningInfo.MissedBlocksCounter).
-				Msg("Finished querying validator signing info")
-
-			metrics.missedBlocksGauge.With(prometheus.Labels{
-				"moniker": validator.Validator.Description.Moniker,
-				"address": validatorAddress.String(),
-			}).Set(float64(slashingRes.ValSigningInfo.MissedBlocksCounter))
-		}()
-	*/
-	return validator
+		// ordered by height
+		metrics.lastSlashFractionGauge.With(labels).Set(fractions[len(fractions)-1])
+
+		height, err := s.LastSlashHeight(ctx, validator.OperatorAddress, len(slashes))
+		if err != nil {
+			sublogger.Error().
+				Str("address", validator.OperatorAddress).
+				Err(err).
+				Msg("Could not get validator last slash height")
+			return
+		}
+		metrics.lastSlashHeightGauge.With(labels).Set(float64(height))
+	}()
 }
 
-func GetValidatorBasicMetricsTM(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorMetrics, s *Service, config *ServiceConfig, moniker string, validatorAddress string, validatorCons string) {
+// getValidatorStakePosition tells where the validator stands in the set: its voting power, and
+// how far it is from the validator above it and from the edge of the active set.
+func getValidatorStakePosition(metrics *ValidatorMetrics, validatorSet *ValidatorSet, validator stakingtypes.Validator, config *ServiceConfig) {
+	labels := prometheus.Labels{
+		"address": validator.OperatorAddress,
+		"moniker": validator.Description.Moniker,
+	}
+	tokenLabels := prometheus.Labels{
+		"address": validator.OperatorAddress,
+		"moniker": validator.Description.Moniker,
+		"denom":   config.Denom,
+	}
+
+	share, cumulative := validatorSet.VotingPowerShare(validator.OperatorAddress)
+	metrics.votingPowerShareGauge.With(labels).Set(share)
+	metrics.cumulativeVotingPowerShareGauge.With(labels).Set(cumulative)
+
+	if lastActive, ok := validatorSet.LastActive(); ok {
+		metrics.tokensAboveActiveSetGauge.With(tokenLabels).Set((tokens(validator) - tokens(lastActive)) / config.DenomCoefficient)
+	}
+
+	if rank := validatorSet.Rank(validator.OperatorAddress); rank > 1 {
+		above := validatorSet.Validators[rank-2]
+		metrics.tokensToNextRankGauge.With(tokenLabels).Set((tokens(above) - tokens(validator)) / config.DenomCoefficient)
+	}
+}
+
+func GetValidatorBasicMetricsTM(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorMetrics, s *Service, config *ServiceConfig, moniker string, validatorAddress string, validatorCons string) {
 
 	wg.Add(1)
 	go func() {
@@ -363,15 +811,9 @@ func GetValidatorBasicMetricsTM(wg *sync.WaitGroup, sublogger *zerolog.Logger, m
 			Str("consaddress", validatorCons).
 			Msg("Started querying validator signing info")
 		queryStart := time.Now()
-		//	interfaceRegistry := codectypes.NewInterfaceRegistry()
-
-		//crytpocode.RegisterInterfaces(interfaceRegistry)
 
-		slashingClient := slashingtypes.NewQueryClient(s.GrpcConn)
-		slashingRes, err := slashingClient.SigningInfo(
-			context.Background(),
-			&slashingtypes.QuerySigningInfoRequest{ConsAddress: validatorCons},
-		)
+		validatorSet := s.ValidatorSet(ctx, sublogger)
+		signingInfo, err := s.SigningInfo(ctx, validatorSet, validatorCons)
 		if err != nil {
 			sublogger.Error().
 				Str("consaddress", validatorCons).
@@ -383,60 +825,99 @@ func GetValidatorBasicMetricsTM(wg *sync.WaitGroup, sublogger *zerolog.Logger, m
 		sublogger.Debug().
 			Str("consaddress", validatorCons).
 			Float64("request-time", time.Since(queryStart).Seconds()).
-			Msg("Finished querying validator signing info")
-
-		sublogger.Debug().
-			Str("consaddress", validatorCons).
-			Int64("missedBlocks", slashingRes.ValSigningInfo.MissedBlocksCounter).
+			Int64("missedBlocks", signingInfo.MissedBlocksCounter).
 			Msg("Finished querying validator signing info")
 
 		metrics.missedBlocksGauge.With(prometheus.Labels{
 			"moniker": moniker,
 			"address": validatorAddress,
-		}).Set(float64(slashingRes.ValSigningInfo.MissedBlocksCounter))
+		}).Set(float64(signingInfo.MissedBlocksCounter))
+		metrics.tombstonedGauge.With(prometheus.Labels{
+			"moniker": moniker,
+			"address": validatorAddress,
+		}).Set(boolToFloat64(signingInfo.Tombstoned))
+		metrics.jailedUntilGauge.With(prometheus.Labels{
+			"moniker": moniker,
+			"address": validatorAddress,
+		}).Set(unixSeconds(signingInfo.JailedUntil))
+		metrics.startHeightGauge.With(prometheus.Labels{
+			"moniker": moniker,
+			"address": validatorAddress,
+		}).Set(float64(signingInfo.StartHeight))
+		metrics.indexOffsetGauge.With(prometheus.Labels{
+			"moniker": moniker,
+			"address": validatorAddress,
+		}).Set(float64(signingInfo.IndexOffset))
+
+		maxMissed, ok := validatorSet.MaxMissedBlocks()
+		if !ok {
+			return
+		}
+		remaining := max(maxMissed-signingInfo.MissedBlocksCounter, 0)
+		metrics.missedBlocksRemainingGauge.With(prometheus.Labels{
+			"moniker": moniker,
+			"address": validatorAddress,
+		}).Set(float64(remaining))
+		metrics.missedBlocksWindowUsedGauge.With(prometheus.Labels{
+			"moniker": moniker,
+			"address": validatorAddress,
+		}).Set(float64(signingInfo.MissedBlocksCounter) / float64(validatorSet.SlashingParams.SignedBlocksWindow) * 100)
+		// there is nothing to count down to once the validator is jailed or tombstoned
+		jailed := signingInfo.Tombstoned || signingInfo.JailedUntil.After(time.Now())
+		if validatorSet.BlockTime > 0 && !jailed {
+			// the validator is jailed on the first miss past the allowed ones
+			metrics.secondsUntilJailGauge.With(prometheus.Labels{
+				"moniker": moniker,
+				"address": validatorAddress,
+			}).Set(float64(remaining+1) * validatorSet.BlockTime)
+		}
 	}()
 
 }
 
-func getValidatorExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorExtendedMetrics, s *Service, config *ServiceConfig, validatorAddress sdk.ValAddress, moniker string, validator *stakingtypes.QueryValidatorResponse) {
+func getValidatorExtendedMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *ValidatorExtendedMetrics, s *Service, config *ServiceConfig, prices map[string]float64, validatorAddress sdk.ValAddress, moniker string, validator *stakingtypes.Validator) {
+	operatorAddress := config.ValAddressString(validatorAddress)
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Msg("Started querying validator delegations")
 		queryStart := time.Now()
 
 		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-		stakingRes, err := stakingClient.ValidatorDelegations(
-			context.Background(),
-			&stakingtypes.QueryValidatorDelegationsRequest{
-				ValidatorAddr: validatorAddress.String(),
-				Pagination: &querytypes.PageRequest{
-					Limit: config.Limit,
-				},
-			},
-		)
+		delegations, err := Paginate(ctx, s, "staking.ValidatorDelegations",
+			func(ctx context.Context, page *querytypes.PageRequest) ([]stakingtypes.DelegationResponse, *querytypes.PageResponse, error) {
+				res, err := stakingClient.ValidatorDelegations(
+					ctx,
+					&stakingtypes.QueryValidatorDelegationsRequest{
+						ValidatorAddr: operatorAddress,
+						Pagination:    page,
+					},
+				)
+				return res.GetDelegationResponses(), res.GetPagination(), err
+			})
 		if err != nil {
 			sublogger.Error().
-				Str("address", validatorAddress.String()).
+				Str("address", operatorAddress).
 				Err(err).
 				Msg("Could not get validator delegations")
 			return
 		}
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying validator delegations")
 
-		for _, delegation := range stakingRes.DelegationResponses {
+		for _, delegation := range delegations {
 			value, err := strconv.ParseFloat(delegation.Balance.Amount.String(), 64)
 			if err != nil {
 				log.Error().
 					Err(err).
-					Str("address", validatorAddress.String()).
+					Str("address", operatorAddress).
 					Msg("Could not convert delegation entry")
 			} else {
 				metrics.delegationsGauge.With(prometheus.Labels{
@@ -454,42 +935,47 @@ func getValidatorExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger,
 		defer wg.Done()
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Msg("Started querying validator commission")
 		queryStart := time.Now()
 
 		distributionClient := distributiontypes.NewQueryClient(s.GrpcConn)
 		distributionRes, err := distributionClient.ValidatorCommission(
-			context.Background(),
-			&distributiontypes.QueryValidatorCommissionRequest{ValidatorAddress: validatorAddress.String()},
+			ctx,
+			&distributiontypes.QueryValidatorCommissionRequest{ValidatorAddress: operatorAddress},
 		)
 		if err != nil {
 			sublogger.Error().
-				Str("address", validatorAddress.String()).
+				Str("address", operatorAddress).
 				Err(err).
 				Msg("Could not get validator commission")
 			return
 		}
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying validator commission")
 
 		for _, commission := range distributionRes.Commission.Commission {
-			// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
-			value, err := strconv.ParseFloat(commission.Amount.String(), 64)
+			value, unit, err := s.Denoms.Value(commission.Denom, commission.Amount.String())
 			if err != nil {
 				log.Error().
 					Err(err).
-					Str("address", validatorAddress.String()).
+					Str("address", operatorAddress).
 					Msg("Could not get validator commission")
 			} else {
 				metrics.commissionGauge.With(prometheus.Labels{
-					"address": validatorAddress.String(),
+					"address":       operatorAddress,
+					"moniker":       moniker,
+					"denom":         commission.Denom,
+					"display_denom": unit.Display,
+				}).Set(value)
+				setUSD(metrics.commissionUSDGauge, prometheus.Labels{
+					"address": operatorAddress,
 					"moniker": moniker,
-					"denom":   config.Denom,
-				}).Set(value / config.DenomCoefficient)
+					"denom":   commission.Denom,
+				}, prices, commission.Denom, value)
 			}
 		}
 	}()
@@ -499,41 +985,46 @@ func getValidatorExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger,
 		defer wg.Done()
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Msg("Started querying validator rewards")
 		queryStart := time.Now()
 
 		distributionClient := distributiontypes.NewQueryClient(s.GrpcConn)
 		distributionRes, err := distributionClient.ValidatorOutstandingRewards(
-			context.Background(),
-			&distributiontypes.QueryValidatorOutstandingRewardsRequest{ValidatorAddress: validatorAddress.String()},
+			ctx,
+			&distributiontypes.QueryValidatorOutstandingRewardsRequest{ValidatorAddress: operatorAddress},
 		)
 		if err != nil {
 			sublogger.Error().
-				Str("address", validatorAddress.String()).
+				Str("address", operatorAddress).
 				Err(err).
 				Msg("Could not get validator rewards")
 			return
 		}
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying validator rewards")
 
 		for _, reward := range distributionRes.Rewards.Rewards {
-			// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
-			if value, err := strconv.ParseFloat(reward.Amount.String(), 64); err != nil {
+			if value, unit, err := s.Denoms.Value(reward.Denom, reward.Amount.String()); err != nil {
 				sublogger.Error().
-					Str("address", validatorAddress.String()).
+					Str("address", operatorAddress).
 					Err(err).
 					Msg("Could not get reward")
 			} else {
 				metrics.rewardsGauge.With(prometheus.Labels{
-					"address": validatorAddress.String(),
+					"address":       operatorAddress,
+					"moniker":       moniker,
+					"denom":         reward.Denom,
+					"display_denom": unit.Display,
+				}).Set(value)
+				setUSD(metrics.rewardsUSDGauge, prometheus.Labels{
+					"address": operatorAddress,
 					"moniker": moniker,
-					"denom":   config.Denom,
-				}).Set(value / config.DenomCoefficient)
+					"denom":   reward.Denom,
+				}, prices, reward.Denom, value)
 			}
 		}
 	}()
@@ -543,36 +1034,40 @@ func getValidatorExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger,
 		defer wg.Done()
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Msg("Started querying validator unbonding delegations")
 		queryStart := time.Now()
 
 		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-		stakingRes, err := stakingClient.ValidatorUnbondingDelegations(
-			context.Background(),
-			&stakingtypes.QueryValidatorUnbondingDelegationsRequest{ValidatorAddr: validatorAddress.String()},
-		)
+		unbondings, err := Paginate(ctx, s, "staking.ValidatorUnbondingDelegations",
+			func(ctx context.Context, page *querytypes.PageRequest) ([]stakingtypes.UnbondingDelegation, *querytypes.PageResponse, error) {
+				res, err := stakingClient.ValidatorUnbondingDelegations(
+					ctx,
+					&stakingtypes.QueryValidatorUnbondingDelegationsRequest{ValidatorAddr: operatorAddress, Pagination: page},
+				)
+				return res.GetUnbondingResponses(), res.GetPagination(), err
+			})
 		if err != nil {
 			sublogger.Error().
-				Str("address", validatorAddress.String()).
+				Str("address", operatorAddress).
 				Err(err).
 				Msg("Could not get validator unbonding delegations")
 			return
 		}
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying validator unbonding delegations")
 
-		for _, unbonding := range stakingRes.UnbondingResponses {
+		for _, unbonding := range unbondings {
 			var sum float64
 			for _, entry := range unbonding.Entries {
 				value, err := strconv.ParseFloat(entry.Balance.String(), 64)
 				if err != nil {
 					log.Error().
 						Err(err).
-						Str("address", validatorAddress.String()).
+						Str("address", operatorAddress).
 						Msg("Could not convert unbonding delegation entry")
 				} else {
 					sum += value
@@ -593,36 +1088,40 @@ func getValidatorExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger,
 		defer wg.Done()
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Msg("Started querying validator redelegations")
 		queryStart := time.Now()
 
 		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-		stakingRes, err := stakingClient.Redelegations(
-			context.Background(),
-			&stakingtypes.QueryRedelegationsRequest{SrcValidatorAddr: validatorAddress.String()},
-		)
+		redelegations, err := Paginate(ctx, s, "staking.Redelegations",
+			func(ctx context.Context, page *querytypes.PageRequest) ([]stakingtypes.RedelegationResponse, *querytypes.PageResponse, error) {
+				res, err := stakingClient.Redelegations(
+					ctx,
+					&stakingtypes.QueryRedelegationsRequest{SrcValidatorAddr: operatorAddress, Pagination: page},
+				)
+				return res.GetRedelegationResponses(), res.GetPagination(), err
+			})
 		if err != nil {
 			sublogger.Error().
-				Str("address", validatorAddress.String()).
+				Str("address", operatorAddress).
 				Err(err).
 				Msg("Could not get redelegations")
 			return
 		}
 
 		sublogger.Debug().
-			Str("address", validatorAddress.String()).
+			Str("address", operatorAddress).
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying validator redelegations")
 
-		for _, redelegation := range stakingRes.RedelegationResponses {
+		for _, redelegation := range redelegations {
 			var sum float64
 			for _, entry := range redelegation.Entries {
 				value, err := strconv.ParseFloat(entry.Balance.String(), 64)
 				if err != nil {
 					log.Error().
 						Err(err).
-						Str("address", validatorAddress.String()).
+						Str("address", operatorAddress).
 						Msg("Could not convert redelegation entry")
 				} else {
 					sum += value
@@ -639,116 +1138,29 @@ func getValidatorExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger,
 		}
 	}()
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-
-		sublogger.Debug().
-			Str("address", validatorAddress.String()).
-			Msg("Started querying validator other validators")
-		queryStart := time.Now()
-
-		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-		stakingRes, err := stakingClient.Validators(
-			context.Background(),
-			&stakingtypes.QueryValidatorsRequest{
-				Pagination: &querytypes.PageRequest{
-					Limit: config.Limit,
-				},
-			},
-		)
-		if err != nil {
-			sublogger.Error().
-				Str("address", validatorAddress.String()).
-				Err(err).
-				Msg("Could not get other validators")
-			return
-		}
-
-		sublogger.Debug().
-			Str("address", validatorAddress.String()).
-			Float64("request-time", time.Since(queryStart).Seconds()).
-			Msg("Finished querying validator other validators")
-
-		validators := stakingRes.Validators
-
-		// sorting by delegator shares to display rankings (unbonded go last)
-		sort.Slice(validators, func(i, j int) bool {
-			firstShares, firstErr := strconv.ParseFloat(validators[i].DelegatorShares.String(), 64)
-			secondShares, secondErr := strconv.ParseFloat(validators[j].DelegatorShares.String(), 64)
-			if !validators[i].IsBonded() && validators[j].IsBonded() {
-				return false
-			} else if validators[i].IsBonded() && !validators[j].IsBonded() {
-				return true
-			}
-
-			if firstErr != nil || secondErr != nil {
-				sublogger.Error().
-					Err(err).
-					Msg("Error converting delegator shares for sorting")
-				return true
-			}
-
-			return firstShares > secondShares
-		})
-
-		var validatorRank int
-
-		for index, validatorIterated := range validators {
-			if validatorIterated.OperatorAddress == validator.Validator.OperatorAddress {
-				validatorRank = index + 1
-				break
-			}
-		}
-
-		if validatorRank == 0 {
-			sublogger.Warn().
-				Str("address", validatorAddress.String()).
-				Msg("Could not find validator in validators list")
-			return
-		}
-
-		metrics.rankGauge.With(prometheus.Labels{
-			"moniker": moniker,
-			"address": validatorAddress.String(),
-		}).Set(float64(validatorRank))
-
-		sublogger.Debug().
-			Str("address", validatorAddress.String()).
-			Msg("Started querying validator params")
-		queryStart = time.Now()
-
-		paramsRes, err := stakingClient.Params(
-			context.Background(),
-			&stakingtypes.QueryParamsRequest{},
-		)
-		if err != nil {
-			sublogger.Error().
-				Str("address", validatorAddress.String()).
-				Err(err).
-				Msg("Could not get params")
-			return
-		}
-
-		sublogger.Debug().
-			Str("address", validatorAddress.String()).
-			Float64("request-time", time.Since(queryStart).Seconds()).
-			Msg("Finished querying validator params")
+	// the rank comes from the validator set shared with the other collectors
+	validatorSet := s.ValidatorSet(ctx, sublogger)
+	validatorRank := validatorSet.Rank(operatorAddress)
+	if validatorRank == 0 {
+		sublogger.Warn().
+			Str("address", operatorAddress).
+			Msg("Could not find validator in validators list")
+		return
+	}
 
-		// golang doesn't have a ternary operator, so we have to stick with this ugly solution
-		var active float64
+	metrics.rankGauge.With(prometheus.Labels{
+		"moniker": moniker,
+		"address": operatorAddress,
+	}).Set(float64(validatorRank))
 
-		if validatorRank <= int(paramsRes.Params.MaxValidators) {
-			active = 1
-		} else {
-			active = 0
-		}
+	if validatorSet.MaxValidators == 0 {
+		return
+	}
 
-		metrics.isActiveGauge.With(prometheus.Labels{
-			"address": validator.Validator.OperatorAddress,
-			"moniker": moniker,
-		}).Set(active)
-	}()
+	metrics.isActiveGauge.With(prometheus.Labels{
+		"address": validator.OperatorAddress,
+		"moniker": moniker,
+	}).Set(boolToFloat64(validatorSet.Active(operatorAddress)))
 }
 
 func (s *Service) ValidatorHandler(w http.ResponseWriter, r *http.Request) {
@@ -758,7 +1170,7 @@ func (s *Service) ValidatorHandler(w http.ResponseWriter, r *http.Request) {
 		Logger()
 
 	address := r.URL.Query().Get("address")
-	myAddress, err := sdk.ValAddressFromBech32(address)
+	myAddress, err := s.Config.ValAddressFromBech32(address)
 	if err != nil {
 		sublogger.Error().
 			Str("address", address).
@@ -770,16 +1182,23 @@ func (s *Service) ValidatorHandler(w http.ResponseWriter, r *http.Request) {
 	registry := prometheus.NewRegistry()
 	validatorMetrics := NewValidatorMetrics(registry, s.Config)
 	validatorExtendedMetrics := NewValidatorExtendedMetrics(registry, s.Config)
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(WithValidatorSet(ctx), "validator")
 	var wg sync.WaitGroup
 
-	validator := GetValidatorBasicMetrics(&wg, &sublogger, validatorMetrics, s, s.Config, myAddress)
+	// fetched once, for every value of the scrape
+	prices := s.PricesUSD(ctx, &sublogger)
+	validator := GetValidatorBasicMetrics(ctx, &wg, &sublogger, validatorMetrics, s, s.Config, prices, myAddress)
 	if validator != nil {
-		getValidatorExtendedMetrics(&wg, &sublogger, validatorExtendedMetrics, s, s.Config, myAddress, validator.Validator.Description.Moniker, validator)
+		getValidatorExtendedMetrics(ctx, &wg, &sublogger, validatorExtendedMetrics, s, s.Config, prices, myAddress, validator.Description.Moniker, validator)
 	}
 
 	wg.Wait()
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	s.FinishCollector(run)
+
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
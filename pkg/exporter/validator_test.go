@@ -0,0 +1,273 @@
+package exporter
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"cosmossdk.io/math"
+
+	tmservice "github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestMissedBlocksBudget(t *testing.T) {
+	validator, cons := fakeValidator(t, "cosmosvaloper1first", 100, stakingtypes.Bonded, false)
+	slashing := &fakeSlashing{
+		signingInfos: []slashingtypes.ValidatorSigningInfo{{Address: cons.String(), MissedBlocksCounter: 20}},
+		params: slashingtypes.Params{
+			SignedBlocksWindow: 100,
+			MinSignedPerWindow: math.LegacyMustNewDecFromStr("0.5"),
+		},
+	}
+	s := startFakeChain(t, func(server *grpc.Server) {
+		stakingtypes.RegisterQueryServer(server, &fakeStaking{validators: []stakingtypes.Validator{validator}, maxValidators: 1})
+		slashingtypes.RegisterQueryServer(server, slashing)
+	})
+	s.Config.TendermintRPC = startFakeTendermint(t, time.Now())
+	sublogger := s.Log
+
+	metrics := NewValidatorMetrics(prometheus.NewRegistry(), s.Config)
+	var wg sync.WaitGroup
+	GetValidatorBasicMetricsTM(WithValidatorSet(context.Background()), &wg, &sublogger, metrics, s, s.Config, "first", validator.OperatorAddress, cons.String())
+	wg.Wait()
+
+	labels := []string{validator.OperatorAddress, "first"}
+	require.Equal(t, float64(20), testutil.ToFloat64(metrics.missedBlocksGauge.WithLabelValues(labels...)))
+	// 50 misses allowed in the window of 100 blocks
+	require.Equal(t, float64(30), testutil.ToFloat64(metrics.missedBlocksRemainingGauge.WithLabelValues(labels...)))
+	require.Equal(t, float64(20), testutil.ToFloat64(metrics.missedBlocksWindowUsedGauge.WithLabelValues(labels...)))
+	// jailed on the 31st next miss, one every 6 seconds
+	require.Equal(t, float64(31*6), testutil.ToFloat64(metrics.secondsUntilJailGauge.WithLabelValues(labels...)))
+
+	// already jailed, there is no countdown
+	slashing.signingInfos[0].JailedUntil = time.Now().Add(time.Hour)
+	metrics = NewValidatorMetrics(prometheus.NewRegistry(), s.Config)
+	GetValidatorBasicMetricsTM(WithValidatorSet(context.Background()), &wg, &sublogger, metrics, s, s.Config, "first", validator.OperatorAddress, cons.String())
+	wg.Wait()
+	require.Equal(t, float64(30), testutil.ToFloat64(metrics.missedBlocksRemainingGauge.WithLabelValues(labels...)))
+	require.Equal(t, 0, testutil.CollectAndCount(metrics.secondsUntilJailGauge))
+}
+
+func TestSigningInfoDetails(t *testing.T) {
+	jailedUntil := time.Unix(1700000000, 0).UTC()
+	validator, cons := fakeValidator(t, "cosmosvaloper1first", 100, stakingtypes.Unbonding, true)
+	slashing := &fakeSlashing{
+		signingInfos: []slashingtypes.ValidatorSigningInfo{{
+			Address:     cons.String(),
+			StartHeight: 42,
+			IndexOffset: 7,
+			JailedUntil: jailedUntil,
+			Tombstoned:  true,
+		}},
+	}
+	s := startFakeChain(t, func(server *grpc.Server) {
+		stakingtypes.RegisterQueryServer(server, &fakeStaking{validators: []stakingtypes.Validator{validator}, maxValidators: 1})
+		slashingtypes.RegisterQueryServer(server, slashing)
+	})
+	sublogger := s.Log
+
+	metrics := NewValidatorMetrics(prometheus.NewRegistry(), s.Config)
+	var wg sync.WaitGroup
+	GetValidatorBasicMetricsTM(context.Background(), &wg, &sublogger, metrics, s, s.Config, "first", validator.OperatorAddress, cons.String())
+	wg.Wait()
+
+	labels := []string{validator.OperatorAddress, "first"}
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.tombstonedGauge.WithLabelValues(labels...)))
+	require.Equal(t, float64(1700000000), testutil.ToFloat64(metrics.jailedUntilGauge.WithLabelValues(labels...)))
+	require.Equal(t, float64(42), testutil.ToFloat64(metrics.startHeightGauge.WithLabelValues(labels...)))
+	require.Equal(t, float64(7), testutil.ToFloat64(metrics.indexOffsetGauge.WithLabelValues(labels...)))
+
+	// the same for the whole set
+	recorder := httptest.NewRecorder()
+	s.ValidatorsHandler(recorder, httptest.NewRequest("GET", "/metrics/validators", nil))
+	body := recorder.Body.String()
+	require.Contains(t, body, `cosmos_validators_tombstoned{address="cosmosvaloper1first",moniker="cosmosvaloper1first"} 1`)
+	require.Contains(t, body, `cosmos_validators_jailed_until{address="cosmosvaloper1first",moniker="cosmosvaloper1first"} 1.7e+09`)
+	require.Contains(t, body, `cosmos_validators_start_height{address="cosmosvaloper1first",moniker="cosmosvaloper1first"} 42`)
+	require.Contains(t, body, `cosmos_validators_index_offset{address="cosmosvaloper1first",moniker="cosmosvaloper1first"} 7`)
+}
+
+func TestValidatorStakePosition(t *testing.T) {
+	first, _ := fakeValidator(t, "first", 3000000, stakingtypes.Bonded, false)
+	second, _ := fakeValidator(t, "second", 1500000, stakingtypes.Bonded, false)
+	third, _ := fakeValidator(t, "third", 500000, stakingtypes.Bonded, false)
+	validators := []stakingtypes.Validator{first, second, third}
+	validatorSet := NewValidatorSet(validators, nil, 2)
+
+	config := &ServiceConfig{Denom: "atom", DenomCoefficient: 1000000}
+	metrics := NewValidatorMetrics(prometheus.NewRegistry(), config)
+	for _, validator := range validators {
+		getValidatorStakePosition(metrics, validatorSet, validator, config)
+	}
+
+	require.Equal(t, 0.3, testutil.ToFloat64(metrics.votingPowerShareGauge.WithLabelValues("second", "second")))
+	require.Equal(t, 0.9, testutil.ToFloat64(metrics.cumulativeVotingPowerShareGauge.WithLabelValues("second", "second")))
+	require.Equal(t, 1.5, testutil.ToFloat64(metrics.tokensAboveActiveSetGauge.WithLabelValues("first", "first", "atom")))
+	require.Equal(t, float64(-1), testutil.ToFloat64(metrics.tokensAboveActiveSetGauge.WithLabelValues("third", "third", "atom")))
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.tokensToNextRankGauge.WithLabelValues("third", "third", "atom")))
+	// nobody to overtake
+	require.Equal(t, 2, testutil.CollectAndCount(metrics.tokensToNextRankGauge))
+}
+
+func TestValidatorCommission(t *testing.T) {
+	validator, _ := fakeValidator(t, "first", 100, stakingtypes.Bonded, false)
+	validator.Commission = stakingtypes.NewCommissionWithTime(
+		math.LegacyMustNewDecFromStr("0.05"),
+		math.LegacyMustNewDecFromStr("0.2"),
+		math.LegacyMustNewDecFromStr("0.01"),
+		time.Unix(1700000000, 0).UTC(),
+	)
+	validator.MinSelfDelegation = math.NewInt(2000000)
+
+	config := &ServiceConfig{Denom: "atom", DenomCoefficient: 1000000}
+	metrics := NewValidatorMetrics(prometheus.NewRegistry(), config)
+	sublogger := zerolog.Nop()
+	getValidatorCommission(metrics, validator, config, &sublogger)
+
+	require.Equal(t, 0.2, testutil.ToFloat64(metrics.commissionMaxRateGauge.WithLabelValues("first", "first")))
+	require.Equal(t, 0.01, testutil.ToFloat64(metrics.commissionMaxChangeRateGauge.WithLabelValues("first", "first")))
+	require.Equal(t, float64(1700000000), testutil.ToFloat64(metrics.commissionUpdateTimeGauge.WithLabelValues("first", "first")))
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.minSelfDelegationGauge.WithLabelValues("first", "first", "atom")))
+}
+
+func TestValidatorSelfDelegation(t *testing.T) {
+	bonded := sdk.ValAddress([]byte("bonded-operator-key1"))
+	unbonded := sdk.ValAddress([]byte("unbonded-operator-k1"))
+	staking := &fakeStaking{
+		delegations: []stakingtypes.DelegationResponse{{
+			Delegation: stakingtypes.Delegation{
+				DelegatorAddress: sdk.MustBech32ifyAddressBytes("cosmos", bonded),
+				ValidatorAddress: sdk.MustBech32ifyAddressBytes("cosmosvaloper", bonded),
+			},
+			Balance: sdk.NewInt64Coin("uatom", 3500000),
+		}},
+	}
+	s := startFakeChain(t, func(server *grpc.Server) {
+		stakingtypes.RegisterQueryServer(server, staking)
+	})
+	s.Config.Denom = "atom"
+	s.Config.DenomCoefficient = 1000000
+	sublogger := s.Log
+
+	metrics := NewValidatorMetrics(prometheus.NewRegistry(), s.Config)
+	var wg sync.WaitGroup
+	for _, operator := range []sdk.ValAddress{bonded, unbonded} {
+		validator, _ := fakeValidator(t, s.Config.ValAddressString(operator), 100, stakingtypes.Bonded, false)
+		validator.MinSelfDelegation = math.NewInt(1000000)
+		GetValidatorSelfDelegationMetrics(context.Background(), &wg, &sublogger, metrics, s, s.Config, validator)
+	}
+	wg.Wait()
+
+	bondedLabels := []string{s.Config.ValAddressString(bonded), s.Config.ValAddressString(bonded), "atom"}
+	require.Equal(t, 3.5, testutil.ToFloat64(metrics.selfDelegationGauge.WithLabelValues(bondedLabels...)))
+	require.Equal(t, 2.5, testutil.ToFloat64(metrics.selfDelegationMarginGauge.WithLabelValues(bondedLabels...)))
+
+	// no delegation left, the validator is below its minimum
+	unbondedLabels := []string{s.Config.ValAddressString(unbonded), s.Config.ValAddressString(unbonded), "atom"}
+	require.Equal(t, float64(0), testutil.ToFloat64(metrics.selfDelegationGauge.WithLabelValues(unbondedLabels...)))
+	require.Equal(t, float64(-1), testutil.ToFloat64(metrics.selfDelegationMarginGauge.WithLabelValues(unbondedLabels...)))
+}
+
+// fakeDistribution serves the slash events of a fake chain, at the height they happened at.
+// The pagination starts at the height in its key, as the events are keyed by height in the
+// store.
+type fakeDistribution struct {
+	distributiontypes.UnimplementedQueryServer
+
+	slashes map[string][]fakeSlash
+	queries atomic.Int32
+}
+
+type fakeSlash struct {
+	height int64
+	event  distributiontypes.ValidatorSlashEvent
+}
+
+func (f *fakeDistribution) ValidatorSlashes(_ context.Context, req *distributiontypes.QueryValidatorSlashesRequest) (*distributiontypes.QueryValidatorSlashesResponse, error) {
+	f.queries.Add(1)
+	from := int64(0)
+	if key := req.Pagination.GetKey(); len(key) >= 8 {
+		from = int64(binary.BigEndian.Uint64(key))
+	}
+
+	res := &distributiontypes.QueryValidatorSlashesResponse{}
+	for _, slash := range f.slashes[req.ValidatorAddress] {
+		if slash.height < from {
+			continue
+		}
+		if limit := req.Pagination.GetLimit(); limit > 0 && uint64(len(res.Slashes)) == limit {
+			break
+		}
+		res.Slashes = append(res.Slashes, slash.event)
+	}
+	return res, nil
+}
+
+func TestValidatorSlashes(t *testing.T) {
+	distribution := &fakeDistribution{slashes: map[string][]fakeSlash{
+		"slashed": {
+			{height: 1200, event: distributiontypes.ValidatorSlashEvent{ValidatorPeriod: 12, Fraction: math.LegacyMustNewDecFromStr("0.0001")}},
+			{height: 98765, event: distributiontypes.ValidatorSlashEvent{ValidatorPeriod: 40, Fraction: math.LegacyMustNewDecFromStr("0.05")}},
+		},
+	}}
+	node := &fakeNode{}
+	node.height.Store(123456)
+	s := startFakeChain(t, func(server *grpc.Server) {
+		distributiontypes.RegisterQueryServer(server, distribution)
+		tmservice.RegisterServiceServer(server, node)
+	})
+	s.SlashHeights = NewSlashHeights()
+	sublogger := s.Log
+
+	scrape := func() *ValidatorMetrics {
+		metrics := NewValidatorMetrics(prometheus.NewRegistry(), s.Config)
+		var wg sync.WaitGroup
+		for _, operator := range []string{"slashed", "clean"} {
+			validator, _ := fakeValidator(t, operator, 100, stakingtypes.Bonded, false)
+			GetValidatorSlashesMetrics(context.Background(), &wg, &sublogger, metrics, s, s.Config, validator)
+		}
+		wg.Wait()
+		return metrics
+	}
+	metrics := scrape()
+
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.slashesGauge.WithLabelValues("slashed", "slashed")))
+	require.Equal(t, 0.05, testutil.ToFloat64(metrics.lastSlashFractionGauge.WithLabelValues("slashed", "slashed")))
+	require.Equal(t, float64(98765), testutil.ToFloat64(metrics.lastSlashHeightGauge.WithLabelValues("slashed", "slashed")))
+	// the second slash burnt 5% of the 99.99% left by the first one
+	require.InDelta(t, 0.050095, testutil.ToFloat64(metrics.slashedFractionGauge.WithLabelValues("slashed", "slashed")), 1e-9)
+
+	require.Equal(t, float64(0), testutil.ToFloat64(metrics.slashesGauge.WithLabelValues("clean", "clean")))
+	require.Equal(t, float64(0), testutil.ToFloat64(metrics.slashedFractionGauge.WithLabelValues("clean", "clean")))
+	// never slashed, nothing to tell
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.lastSlashFractionGauge))
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.lastSlashHeightGauge))
+
+	// bisected between 0 and the latest block
+	require.LessOrEqual(t, distribution.queries.Load(), int32(2+18))
+
+	// cached until the validator is slashed again
+	queries := distribution.queries.Load()
+	metrics = scrape()
+	require.Equal(t, float64(98765), testutil.ToFloat64(metrics.lastSlashHeightGauge.WithLabelValues("slashed", "slashed")))
+	require.Equal(t, queries+2, distribution.queries.Load())
+
+	distribution.slashes["slashed"] = append(distribution.slashes["slashed"],
+		fakeSlash{height: 120000, event: distributiontypes.ValidatorSlashEvent{ValidatorPeriod: 41, Fraction: math.LegacyMustNewDecFromStr("0.01")}})
+	metrics = scrape()
+	require.Equal(t, float64(120000), testutil.ToFloat64(metrics.lastSlashHeightGauge.WithLabelValues("slashed", "slashed")))
+}
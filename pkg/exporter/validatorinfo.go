@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	crytpocode "github.com/cosmos/cosmos-sdk/crypto/codec"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// validatorInfoFields are the labels of cosmos_validator_info which identify the operator.
+var validatorInfoFields = []string{"moniker", "identity", "website", "security_contact", "details_hash", "consensus_pubkey"}
+
+// validatorInfo returns the description and consensus key of validator, keyed by
+// validatorInfoFields. The details can be long, only their hash is kept. The consensus key is
+// left empty when it cannot be decoded.
+func validatorInfo(validator stakingtypes.Validator) (map[string]string, error) {
+	details := sha256.Sum256([]byte(validator.Description.Details))
+	info := map[string]string{
+		"moniker":          validator.Description.Moniker,
+		"identity":         validator.Description.Identity,
+		"website":          validator.Description.Website,
+		"security_contact": validator.Description.SecurityContact,
+		"details_hash":     hex.EncodeToString(details[:]),
+		"consensus_pubkey": "",
+	}
+
+	if validator.ConsensusPubkey == nil {
+		return info, nil
+	}
+	if validator.ConsensusPubkey.GetCachedValue() == nil {
+		// queried on its own rather than with the validator set
+		interfaceRegistry := codectypes.NewInterfaceRegistry()
+		crytpocode.RegisterInterfaces(interfaceRegistry)
+		if err := validator.UnpackInterfaces(interfaceRegistry); err != nil {
+			return info, err
+		}
+	}
+
+	pubKey, err := validator.ConsPubKey()
+	if err != nil {
+		return info, err
+	}
+	info["consensus_pubkey"] = base64.StdEncoding.EncodeToString(pubKey.Bytes())
+
+	return info, nil
+}
+
+// setValidatorInfo exports the info of validator and observes its changes. Without its
+// consensus key, the info would look like a key rotation, so it is left out of the scrape.
+func setValidatorInfo(metrics *ValidatorMetrics, s *Service, validator stakingtypes.Validator, sublogger *zerolog.Logger) {
+	info, err := validatorInfo(validator)
+	if err != nil {
+		sublogger.Error().
+			Str("address", validator.OperatorAddress).
+			Err(err).
+			Msg("Could not get validator consensus pubkey")
+		return
+	}
+
+	infoLabels := prometheus.Labels{"address": validator.OperatorAddress}
+	for field, value := range info {
+		infoLabels[field] = value
+	}
+	metrics.infoGauge.With(infoLabels).Set(1)
+	s.ValidatorInfos.Observe(validator.OperatorAddress, info)
+}
+
+// ValidatorInfoTracker remembers the description and consensus key of every monitored
+// validator, and counts the changes between two scrapes. Its counter lives for the whole
+// process and is served on every endpoint.
+type ValidatorInfoTracker struct {
+	log zerolog.Logger
+
+	mu   sync.Mutex
+	seen map[string]map[string]string
+
+	changesCounter *prometheus.CounterVec
+}
+
+func NewValidatorInfoTracker(reg prometheus.Registerer, config *ServiceConfig, log zerolog.Logger) *ValidatorInfoTracker {
+	t := &ValidatorInfoTracker{
+		log:  log,
+		seen: make(map[string]map[string]string),
+		changesCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cosmos_validator_info_changes_total",
+				Help:        "Number of changes of a field of cosmos_validator_info observed since the exporter started",
+				ConstLabels: config.ConstLabels,
+			},
+			[]string{"address", "field"},
+		),
+	}
+	reg.MustRegister(t.changesCounter)
+
+	return t
+}
+
+// Observe compares info, as returned by validatorInfo, with the one of the previous scrape.
+// The first scrape only records it.
+func (t *ValidatorInfoTracker) Observe(address string, info map[string]string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous, ok := t.seen[address]
+	for _, field := range validatorInfoFields {
+		counter := t.changesCounter.WithLabelValues(address, field)
+		if ok && previous[field] != info[field] {
+			counter.Inc()
+
+			t.log.Warn().
+				Str("address", address).
+				Str("field", field).
+				Str("previous", previous[field]).
+				Str("value", info[field]).
+				Msg("Validator info changed")
+		}
+	}
+
+	t.seen[address] = info
+}
@@ -0,0 +1,79 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func TestValidatorInfo(t *testing.T) {
+	validator, _ := fakeValidator(t, "first", 100, stakingtypes.Bonded, false)
+	validator.Description = stakingtypes.NewDescription("first", "ABCDEF0123456789", "https://first.example", "security@first.example", "")
+
+	info, err := validatorInfo(validator)
+	require.NoError(t, err)
+	require.Equal(t, "ABCDEF0123456789", info["identity"])
+	require.Equal(t, "security@first.example", info["security_contact"])
+	// sha256 of nothing
+	require.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", info["details_hash"])
+	require.NotEmpty(t, info["consensus_pubkey"])
+
+	// not unpacked yet, as when queried on its own
+	pubKeyAny, err := codectypes.NewAnyWithValue(ed25519.GenPrivKey().PubKey())
+	require.NoError(t, err)
+	validator.ConsensusPubkey = &codectypes.Any{TypeUrl: pubKeyAny.TypeUrl, Value: pubKeyAny.Value}
+	unpacked, err := validatorInfo(validator)
+	require.NoError(t, err)
+	require.NotEqual(t, info["consensus_pubkey"], unpacked["consensus_pubkey"])
+}
+
+func TestValidatorInfoTracker(t *testing.T) {
+	tracker := NewValidatorInfoTracker(prometheus.NewRegistry(), &ServiceConfig{}, zerolog.Nop())
+	validator, _ := fakeValidator(t, "first", 100, stakingtypes.Bonded, false)
+
+	info, err := validatorInfo(validator)
+	require.NoError(t, err)
+	tracker.Observe("first", info)
+	tracker.Observe("first", info)
+	require.Equal(t, len(validatorInfoFields), testutil.CollectAndCount(tracker.changesCounter))
+	require.Equal(t, float64(0), testutil.ToFloat64(tracker.changesCounter.WithLabelValues("first", "moniker")))
+
+	// renamed, and the consensus key rotated
+	rotated, _ := fakeValidator(t, "first", 100, stakingtypes.Bonded, false)
+	rotated.Description.Moniker = "impostor"
+	info, err = validatorInfo(rotated)
+	require.NoError(t, err)
+	tracker.Observe("first", info)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(tracker.changesCounter.WithLabelValues("first", "moniker")))
+	require.Equal(t, float64(1), testutil.ToFloat64(tracker.changesCounter.WithLabelValues("first", "consensus_pubkey")))
+	require.Equal(t, float64(0), testutil.ToFloat64(tracker.changesCounter.WithLabelValues("first", "website")))
+}
+
+func TestSetValidatorInfo(t *testing.T) {
+	config := &ServiceConfig{}
+	s := &Service{ValidatorInfos: NewValidatorInfoTracker(prometheus.NewRegistry(), config, zerolog.Nop())}
+	sublogger := zerolog.Nop()
+	validator, _ := fakeValidator(t, "first", 100, stakingtypes.Bonded, false)
+
+	metrics := NewValidatorMetrics(prometheus.NewRegistry(), config)
+	setValidatorInfo(metrics, s, validator, &sublogger)
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.infoGauge))
+
+	// a consensus key failing to decode is not a rotation
+	broken := validator
+	broken.ConsensusPubkey = &codectypes.Any{TypeUrl: "/unknown.PubKey", Value: []byte{1}}
+	metrics = NewValidatorMetrics(prometheus.NewRegistry(), config)
+	setValidatorInfo(metrics, s, broken, &sublogger)
+	require.Equal(t, 0, testutil.CollectAndCount(metrics.infoGauge))
+	setValidatorInfo(metrics, s, validator, &sublogger)
+
+	require.Equal(t, float64(0), testutil.ToFloat64(s.ValidatorInfos.changesCounter.WithLabelValues("first", "consensus_pubkey")))
+}
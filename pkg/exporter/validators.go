@@ -1,13 +1,10 @@
 package exporter
 
 import (
-	"context"
 	"encoding/hex"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,18 +12,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
 
-	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
-	crytpocode "github.com/cosmos/cosmos-sdk/crypto/codec"
-	sdk "github.com/cosmos/cosmos-sdk/types"
-	querytypes "github.com/cosmos/cosmos-sdk/types/query"
-	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 )
 
 func (s *Service) ValidatorsHandler(w http.ResponseWriter, r *http.Request) {
-	interfaceRegistry := codectypes.NewInterfaceRegistry()
-	crytpocode.RegisterInterfaces(interfaceRegistry)
-
 	requestStart := time.Now()
 	config := s.Config
 	sublogger := s.Log.With().
@@ -42,6 +31,33 @@ func (s *Service) ValidatorsHandler(w http.ResponseWriter, r *http.Request) {
 		[]string{"address", "moniker"},
 	)
 
+	validatorsCommissionMaxRateGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_commission_max_rate",
+			Help:        "Maximum commission rate the Cosmos-based blockchain validator can ever charge",
+			ConstLabels: config.ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsCommissionMaxChangeRateGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_commission_max_change_rate",
+			Help:        "Maximum daily increase of the commission rate of the Cosmos-based blockchain validator",
+			ConstLabels: config.ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsCommissionUpdateTimeGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_commission_update_time",
+			Help:        "Unix timestamp of the last commission change of the Cosmos-based blockchain validator",
+			ConstLabels: config.ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
 	validatorsStatusGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cosmos_validators_status",
@@ -96,6 +112,42 @@ func (s *Service) ValidatorsHandler(w http.ResponseWriter, r *http.Request) {
 		[]string{"address", "moniker"},
 	)
 
+	validatorsTombstonedGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_tombstoned",
+			Help:        "1 if the Cosmos-based blockchain validator is tombstoned, 0 if no",
+			ConstLabels: config.ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsJailedUntilGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_jailed_until",
+			Help:        "Unix timestamp the Cosmos-based blockchain validator can be unjailed at, 0 if it never was jailed",
+			ConstLabels: config.ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsStartHeightGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_start_height",
+			Help:        "Height the Cosmos-based blockchain validator started signing at",
+			ConstLabels: config.ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
+	validatorsIndexOffsetGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_index_offset",
+			Help:        "Index of the Cosmos-based blockchain validator in its signing window",
+			ConstLabels: config.ConstLabels,
+		},
+		[]string{"address", "moniker"},
+	)
+
 	validatorsRankGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:        "cosmos_validators_rank",
@@ -114,132 +166,48 @@ func (s *Service) ValidatorsHandler(w http.ResponseWriter, r *http.Request) {
 		[]string{"address", "pubkey_hash", "moniker"},
 	)
 
+	validatorsNakamotoCoefficientGauge := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:        "cosmos_validators_nakamoto_coefficient",
+			Help:        "Smallest number of Cosmos-based blockchain validators holding more than a third of the voting power",
+			ConstLabels: config.ConstLabels,
+		},
+	)
+
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(validatorsCommissionGauge)
+	registry.MustRegister(validatorsCommissionMaxRateGauge)
+	registry.MustRegister(validatorsCommissionMaxChangeRateGauge)
+	registry.MustRegister(validatorsCommissionUpdateTimeGauge)
 	registry.MustRegister(validatorsStatusGauge)
 	registry.MustRegister(validatorsJailedGauge)
 	registry.MustRegister(validatorsTokensGauge)
 	registry.MustRegister(validatorsDelegatorSharesGauge)
 	registry.MustRegister(validatorsMinSelfDelegationGauge)
 	registry.MustRegister(validatorsMissedBlocksGauge)
+	registry.MustRegister(validatorsTombstonedGauge)
+	registry.MustRegister(validatorsJailedUntilGauge)
+	registry.MustRegister(validatorsStartHeightGauge)
+	registry.MustRegister(validatorsIndexOffsetGauge)
 	registry.MustRegister(validatorsRankGauge)
 	registry.MustRegister(validatorsIsActiveGauge)
+	registry.MustRegister(validatorsNakamotoCoefficientGauge)
 
-	var validators []stakingtypes.Validator
-	var signingInfos []slashingtypes.ValidatorSigningInfo
-	var validatorSetLength uint32
-
-	var wg sync.WaitGroup
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		sublogger.Debug().Msg("Started querying validators")
-		queryStart := time.Now()
-
-		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-
-		offset := uint64(0)
-		for {
-			validatorsResponse, err := stakingClient.Validators(
-				context.Background(),
-				&stakingtypes.QueryValidatorsRequest{
-					Pagination: &querytypes.PageRequest{
-						Limit:  config.Limit,
-						Offset: offset,
-					},
-				},
-			)
-			if err != nil {
-				sublogger.Error().Err(err).Msg("Could not get validators")
-				return
-			}
-
-			validatorsOnPage := validatorsResponse.GetValidators()
-			if validatorsResponse == nil || len(validatorsOnPage) == 0 {
-				break
-			}
-			validators = append(validators, validatorsOnPage...)
-			offset = uint64(len(validators))
-		}
-
-		sublogger.Debug().
-			Float64("request-time", time.Since(queryStart).Seconds()).
-			Msg("Finished querying validators")
-
-		// sorting by delegator shares to display rankings (unbonded go last)
-		sort.Slice(validators, func(i, j int) bool {
-			if !validators[i].IsBonded() && validators[j].IsBonded() {
-				return false
-			} else if validators[i].IsBonded() && !validators[j].IsBonded() {
-				return true
-			}
-
-			return validators[i].DelegatorShares.BigInt().Cmp(validators[j].DelegatorShares.BigInt()) > 0
-		})
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		sublogger.Debug().Msg("Started querying validators signing infos")
-		queryStart := time.Now()
-
-		slashingClient := slashingtypes.NewQueryClient(s.GrpcConn)
-		signingInfosResponse, err := slashingClient.SigningInfos(
-			context.Background(),
-			&slashingtypes.QuerySigningInfosRequest{
-				Pagination: &querytypes.PageRequest{
-					Limit: config.Limit,
-				},
-			},
-		)
-		if err != nil {
-			sublogger.Error().
-				Err(err).
-				Msg("Could not get validators signing infos")
-			return
-		}
-
-		sublogger.Debug().
-			Float64("request-time", time.Since(queryStart).Seconds()).
-			Msg("Finished querying validator signing infos")
-		signingInfos = signingInfosResponse.Info
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		sublogger.Debug().Msg("Started querying staking params")
-		queryStart := time.Now()
-
-		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-		paramsResponse, err := stakingClient.Params(
-			context.Background(),
-			&stakingtypes.QueryParamsRequest{},
-		)
-		if err != nil {
-			sublogger.Error().
-				Err(err).
-				Msg("Could not get staking params")
-			return
-		}
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "validator_set")
 
-		sublogger.Debug().
-			Float64("request-time", time.Since(queryStart).Seconds()).
-			Msg("Finished querying staking params")
-		validatorSetLength = paramsResponse.Params.MaxValidators
-	}()
-
-	wg.Wait()
+	validatorSet := s.ValidatorSet(ctx, &sublogger)
 
 	sublogger.Info().
-		Int("signingLength", len(signingInfos)).
-		Int("validatorsLength", len(validators)).
+		Int("signingLength", len(validatorSet.SigningInfos)).
+		Int("validatorsLength", len(validatorSet.Validators)).
 		Msg("Validators info")
 
+	validatorsNakamotoCoefficientGauge.Set(float64(validatorSet.NakamotoCoefficient()))
+
 	activeValidators := 0
-	for index, validator := range validators {
+	for index, validator := range validatorSet.Validators {
 		// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
 		rate, err := strconv.ParseFloat(validator.Commission.CommissionRates.Rate.String(), 64)
 		if err != nil {
@@ -254,6 +222,36 @@ func (s *Service) ValidatorsHandler(w http.ResponseWriter, r *http.Request) {
 			}).Set(rate)
 		}
 
+		if value, err := strconv.ParseFloat(validator.Commission.CommissionRates.MaxRate.String(), 64); err != nil {
+			log.Error().
+				Err(err).
+				Str("address", validator.OperatorAddress).
+				Msg("Could not get commission max rate")
+		} else {
+			validatorsCommissionMaxRateGauge.With(prometheus.Labels{
+				"address": validator.OperatorAddress,
+				"moniker": validator.Description.Moniker,
+			}).Set(value)
+		}
+
+		if value, err := strconv.ParseFloat(validator.Commission.CommissionRates.MaxChangeRate.String(), 64); err != nil {
+			log.Error().
+				Err(err).
+				Str("address", validator.OperatorAddress).
+				Msg("Could not get commission max change rate")
+		} else {
+			validatorsCommissionMaxChangeRateGauge.With(prometheus.Labels{
+				"address": validator.OperatorAddress,
+				"moniker": validator.Description.Moniker,
+			}).Set(value)
+		}
+
+		validatorsCommissionUpdateTimeGauge.With(prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+		}).Set(unixSeconds(validator.Commission.UpdateTime))
+		s.Commissions.Observe(validator)
+
 		validatorsStatusGauge.With(prometheus.Labels{
 			"address": validator.OperatorAddress,
 			"moniker": validator.Description.Moniker,
@@ -314,57 +312,39 @@ func (s *Service) ValidatorsHandler(w http.ResponseWriter, r *http.Request) {
 			}).Set(value / config.DenomCoefficient)
 		}
 
-		err = validator.UnpackInterfaces(interfaceRegistry) // Unpack interfaces, to populate the Anys' cached values
-		if err != nil {
+		pubKey := validatorSet.ConsAddress(validator.OperatorAddress)
+		if pubKey == nil {
 			sublogger.Error().
 				Str("address", validator.OperatorAddress).
-				Err(err).
-				Msg("Could not get unpack validator inferfaces")
+				Msg("Could not get validator pubkey")
 		}
 
-		pubKey, err := validator.GetConsAddr()
+		signingInfo, err := s.SigningInfo(ctx, validatorSet, s.Config.ConsAddressString(pubKey))
 		if err != nil {
-			sublogger.Error().
+			sublogger.Debug().
 				Str("address", validator.OperatorAddress).
-				Err(err).
-				Msg("Could not get validator pubkey")
-		}
-		valcons, err := sdk.ConsAddressFromHex(hex.EncodeToString(pubKey))
-		if err != nil {
-			sublogger.Error().
-				Str("address", validator.String()).
-				Err(err).
-				Msg("Could not get validatorcons from ConsAddressFromHex")
-		}
-
-		var signingInfo slashingtypes.ValidatorSigningInfo
-		found := false
-
-		for _, signingInfoIterated := range signingInfos {
-			if valcons.String() == signingInfoIterated.Address {
-				found = true
-				signingInfo = signingInfoIterated
-				break
-			}
+				Msg("Could not get signing info for validator")
+			continue
 		}
 
-		if !found {
-			slashingClient := slashingtypes.NewQueryClient(s.GrpcConn)
-			slashingRes, err := slashingClient.SigningInfo(
-				context.Background(),
-				&slashingtypes.QuerySigningInfoRequest{ConsAddress: valcons.String()},
-			)
-			if err != nil {
-				sublogger.Debug().
-					Str("address", validator.OperatorAddress).
-					Msg("Could not get signing info for validator")
-				continue
-			}
-			found = true
-			signingInfo = slashingRes.ValSigningInfo
-		}
+		validatorsTombstonedGauge.With(prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+		}).Set(boolToFloat64(signingInfo.Tombstoned))
+		validatorsJailedUntilGauge.With(prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+		}).Set(unixSeconds(signingInfo.JailedUntil))
+		validatorsStartHeightGauge.With(prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+		}).Set(float64(signingInfo.StartHeight))
+		validatorsIndexOffsetGauge.With(prometheus.Labels{
+			"address": validator.OperatorAddress,
+			"moniker": validator.Description.Moniker,
+		}).Set(float64(signingInfo.IndexOffset))
 
-		if found && (validator.Status == stakingtypes.Bonded) {
+		if validator.Status == stakingtypes.Bonded {
 			validatorsMissedBlocksGauge.With(prometheus.Labels{
 				"address": validator.OperatorAddress,
 				"moniker": validator.Description.Moniker,
@@ -380,29 +360,24 @@ func (s *Service) ValidatorsHandler(w http.ResponseWriter, r *http.Request) {
 			"moniker": validator.Description.Moniker,
 		}).Set(float64(index + 1))
 
-		if validatorSetLength != 0 {
-			// golang doesn't have a ternary operator, so we have to stick with this ugly solution
-			active := float64(1)
-
-			if validator.Jailed {
-				active = 0
+		if validatorSet.MaxValidators != 0 {
+			active := validatorSet.Active(validator.OperatorAddress)
+			if active {
+				activeValidators++
 			}
 
-			if activeValidators == int(validatorSetLength) {
-				active = 0
-			}
-			activeValidators += int(active)
-
 			validatorsIsActiveGauge.With(prometheus.Labels{
 				"address":     validator.OperatorAddress,
 				"moniker":     validator.Description.Moniker,
 				"pubkey_hash": strings.ToUpper(hex.EncodeToString(pubKey)),
-			}).Set(active)
+			}).Set(boolToFloat64(active))
 		}
 	}
 	sublogger.Info().Int("activeValidators", activeValidators).Msg("Active validators")
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	s.FinishCollector(run)
+
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
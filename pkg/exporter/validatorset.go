@@ -0,0 +1,398 @@
+package exporter
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	crytpocode "github.com/cosmos/cosmos-sdk/crypto/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// ValidatorSet is the validator set of the chain as seen by one scrape. It is fetched once with
+// a handful of paginated queries and shared by the validator, validators and voting collectors
+// instead of each of them querying every validator on its own. It also holds the slashing
+// params and the block time, which tell how close the validators are to being jailed.
+type ValidatorSet struct {
+	// Validators are ordered by rank: bonded first, then by delegator shares
+	Validators []stakingtypes.Validator
+	// SigningInfos are keyed by the bech32 consensus address
+	SigningInfos map[string]slashingtypes.ValidatorSigningInfo
+	// MaxValidators is the size of the active set, 0 if the staking params could not be fetched
+	MaxValidators uint32
+	// SlashingParams are nil if they could not be fetched
+	SlashingParams *slashingtypes.Params
+	// BlockTime is the average block time in seconds reported by --tendermint-rpc, 0 if unknown
+	BlockTime float64
+
+	byOperator    map[string]int
+	consAddresses map[string]sdk.ConsAddress
+	active        map[string]bool
+}
+
+// NewValidatorSet indexes validators, which must already be ordered by rank, and signingInfos.
+func NewValidatorSet(validators []stakingtypes.Validator, signingInfos []slashingtypes.ValidatorSigningInfo, maxValidators uint32) *ValidatorSet {
+	interfaceRegistry := codectypes.NewInterfaceRegistry()
+	crytpocode.RegisterInterfaces(interfaceRegistry)
+
+	vs := &ValidatorSet{
+		Validators:    validators,
+		SigningInfos:  make(map[string]slashingtypes.ValidatorSigningInfo, len(signingInfos)),
+		MaxValidators: maxValidators,
+		byOperator:    make(map[string]int, len(validators)),
+		consAddresses: make(map[string]sdk.ConsAddress, len(validators)),
+		active:        make(map[string]bool, len(validators)),
+	}
+	for _, signingInfo := range signingInfos {
+		vs.SigningInfos[signingInfo.Address] = signingInfo
+	}
+
+	activeValidators := 0
+	for index, validator := range validators {
+		vs.byOperator[validator.OperatorAddress] = index
+
+		// Unpack interfaces, to populate the Anys' cached values
+		if err := validator.UnpackInterfaces(interfaceRegistry); err == nil {
+			if consAddress, err := validator.GetConsAddr(); err == nil {
+				vs.consAddresses[validator.OperatorAddress] = consAddress
+			}
+		}
+
+		if !validator.Jailed && activeValidators < int(maxValidators) {
+			vs.active[validator.OperatorAddress] = true
+			activeValidators++
+		}
+	}
+
+	return vs
+}
+
+// Validator returns the validator with the given operator address.
+func (vs *ValidatorSet) Validator(operatorAddress string) (stakingtypes.Validator, bool) {
+	index, ok := vs.byOperator[operatorAddress]
+	if !ok {
+		return stakingtypes.Validator{}, false
+	}
+	return vs.Validators[index], true
+}
+
+// Rank returns the 1-based rank of the validator, 0 if it is not in the set.
+func (vs *ValidatorSet) Rank(operatorAddress string) int {
+	index, ok := vs.byOperator[operatorAddress]
+	if !ok {
+		return 0
+	}
+	return index + 1
+}
+
+// Active tells whether the validator is one of the MaxValidators best ranked validators that
+// are not jailed.
+func (vs *ValidatorSet) Active(operatorAddress string) bool {
+	return vs.active[operatorAddress]
+}
+
+// ConsAddress returns the consensus address of the validator, nil if its consensus pubkey
+// could not be decoded.
+func (vs *ValidatorSet) ConsAddress(operatorAddress string) sdk.ConsAddress {
+	return vs.consAddresses[operatorAddress]
+}
+
+// MaxMissedBlocks returns how many blocks of the signing window a validator may miss without
+// being jailed, false if the slashing params are unknown.
+func (vs *ValidatorSet) MaxMissedBlocks() (int64, bool) {
+	if vs.SlashingParams == nil || vs.SlashingParams.SignedBlocksWindow <= 0 {
+		return 0, false
+	}
+	window := vs.SlashingParams.SignedBlocksWindow
+	// rounded as the slashing keeper does
+	minSigned := vs.SlashingParams.MinSignedPerWindow.MulInt64(window).RoundInt64()
+	return window - minSigned, true
+}
+
+// tokens returns the tokens of the validator as a float, they only feed ratios and gauges.
+func tokens(validator stakingtypes.Validator) float64 {
+	value, err := strconv.ParseFloat(validator.Tokens.String(), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// BondedTokens returns the tokens of the bonded validators, which make the voting power.
+func (vs *ValidatorSet) BondedTokens() float64 {
+	var total float64
+	for _, validator := range vs.Validators {
+		if validator.IsBonded() {
+			total += tokens(validator)
+		}
+	}
+	return total
+}
+
+// VotingPowerShare returns the share of the bonded tokens held by the validator, and the share
+// held by it along with every validator ranked above it. Both are 0 if it is not bonded.
+func (vs *ValidatorSet) VotingPowerShare(operatorAddress string) (share float64, cumulative float64) {
+	index, ok := vs.byOperator[operatorAddress]
+	total := vs.BondedTokens()
+	if !ok || total == 0 || !vs.Validators[index].IsBonded() {
+		return 0, 0
+	}
+	// bonded validators are ranked first
+	var above float64
+	for _, validator := range vs.Validators[:index+1] {
+		above += tokens(validator)
+	}
+	return tokens(vs.Validators[index]) / total, above / total
+}
+
+// LastActive returns the lowest ranked validator of the active set.
+func (vs *ValidatorSet) LastActive() (stakingtypes.Validator, bool) {
+	for index := len(vs.Validators) - 1; index >= 0; index-- {
+		if vs.active[vs.Validators[index].OperatorAddress] {
+			return vs.Validators[index], true
+		}
+	}
+	return stakingtypes.Validator{}, false
+}
+
+// NakamotoCoefficient returns how many validators, taken by rank, hold more than a third of
+// the voting power, enough to halt the chain. It is 0 if nothing is bonded.
+func (vs *ValidatorSet) NakamotoCoefficient() int {
+	total := vs.BondedTokens()
+	if total == 0 {
+		return 0
+	}
+	var cumulative float64
+	for index, validator := range vs.Validators {
+		if !validator.IsBonded() {
+			break
+		}
+		cumulative += tokens(validator)
+		if cumulative > total/3 {
+			return index + 1
+		}
+	}
+	return 0
+}
+
+// sortValidators orders validators by rank: bonded first, then by tokens, as the staking module
+// does. The delegator shares are worth fewer tokens once a validator is slashed.
+func sortValidators(validators []stakingtypes.Validator) {
+	sort.SliceStable(validators, func(i, j int) bool {
+		if validators[i].IsBonded() != validators[j].IsBonded() {
+			return validators[i].IsBonded()
+		}
+		return validators[i].Tokens.GT(validators[j].Tokens)
+	})
+}
+
+// LoadValidatorSet fetches every validator, every signing info, the staking and slashing params
+// and the block time, running the queries concurrently. The validators are ordered by rank. A
+// failing query is logged and leaves its part of the set empty, the callers then fall back to
+// querying the validators they need one by one.
+func (s *Service) LoadValidatorSet(ctx context.Context, sublogger *zerolog.Logger) *ValidatorSet {
+	var validators []stakingtypes.Validator
+	var signingInfos []slashingtypes.ValidatorSigningInfo
+	var maxValidators uint32
+	var slashingParams *slashingtypes.Params
+	var blockTime float64
+
+	var wg sync.WaitGroup
+
+	// initia replaced the staking module, only the signing infos are available there
+	if !s.Config.Initia {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sublogger.Debug().Msg("Started querying validators")
+			queryStart := time.Now()
+
+			stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
+			var err error
+			validators, err = Paginate(ctx, s, "staking.Validators",
+				func(ctx context.Context, page *querytypes.PageRequest) ([]stakingtypes.Validator, *querytypes.PageResponse, error) {
+					res, err := stakingClient.Validators(
+						ctx,
+						&stakingtypes.QueryValidatorsRequest{Pagination: page},
+					)
+					return res.GetValidators(), res.GetPagination(), err
+				})
+			if err != nil {
+				sublogger.Error().Err(err).Msg("Could not get validators")
+				validators = nil
+				return
+			}
+
+			sublogger.Debug().
+				Float64("request-time", time.Since(queryStart).Seconds()).
+				Msg("Finished querying validators")
+
+			sortValidators(validators)
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sublogger.Debug().Msg("Started querying staking params")
+			queryStart := time.Now()
+
+			stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
+			paramsResponse, err := stakingClient.Params(
+				ctx,
+				&stakingtypes.QueryParamsRequest{},
+			)
+			if err != nil {
+				sublogger.Error().
+					Err(err).
+					Msg("Could not get staking params")
+				return
+			}
+
+			sublogger.Debug().
+				Float64("request-time", time.Since(queryStart).Seconds()).
+				Msg("Finished querying staking params")
+			maxValidators = paramsResponse.Params.MaxValidators
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying validators signing infos")
+		queryStart := time.Now()
+
+		slashingClient := slashingtypes.NewQueryClient(s.GrpcConn)
+		var err error
+		signingInfos, err = Paginate(ctx, s, "slashing.SigningInfos",
+			func(ctx context.Context, page *querytypes.PageRequest) ([]slashingtypes.ValidatorSigningInfo, *querytypes.PageResponse, error) {
+				res, err := slashingClient.SigningInfos(
+					ctx,
+					&slashingtypes.QuerySigningInfosRequest{Pagination: page},
+				)
+				return res.GetInfo(), res.GetPagination(), err
+			})
+		if err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not get validators signing infos")
+			signingInfos = nil
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying validator signing infos")
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sublogger.Debug().Msg("Started querying slashing params")
+		queryStart := time.Now()
+
+		slashingClient := slashingtypes.NewQueryClient(s.GrpcConn)
+		paramsResponse, err := slashingClient.Params(
+			ctx,
+			&slashingtypes.QueryParamsRequest{},
+		)
+		if err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not get slashing params")
+			return
+		}
+
+		sublogger.Debug().
+			Float64("request-time", time.Since(queryStart).Seconds()).
+			Msg("Finished querying slashing params")
+		slashingParams = &paramsResponse.Params
+	}()
+
+	if s.Config.TendermintRPC != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			status, err := NewChainStatus(ctx, s.Config)
+			if err != nil {
+				sublogger.Debug().
+					Err(err).
+					Msg("Could not get chain status")
+				return
+			}
+			// a node without history has no average
+			if avg := status.AvgBlockTIme(); avg > 0 && !math.IsInf(avg, 0) {
+				blockTime = avg
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	sublogger.Debug().
+		Int("signingLength", len(signingInfos)).
+		Int("validatorsLength", len(validators)).
+		Msg("Validator set loaded")
+
+	vs := NewValidatorSet(validators, signingInfos, maxValidators)
+	vs.SlashingParams = slashingParams
+	vs.BlockTime = blockTime
+	return vs
+}
+
+// validatorSetKey is the context key of the validator set shared by the collectors of a scrape.
+type validatorSetKey struct{}
+
+type sharedValidatorSet struct {
+	once sync.Once
+	set  *ValidatorSet
+}
+
+// WithValidatorSet returns a context in which everything collected shares a single validator
+// set, loaded the first time it is asked for. A context already sharing one is returned as is.
+func WithValidatorSet(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(validatorSetKey{}).(*sharedValidatorSet); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, validatorSetKey{}, &sharedValidatorSet{})
+}
+
+// ValidatorSet returns the validator set shared in ctx, see WithValidatorSet. Without one, it
+// loads a new set.
+func (s *Service) ValidatorSet(ctx context.Context, sublogger *zerolog.Logger) *ValidatorSet {
+	shared, ok := ctx.Value(validatorSetKey{}).(*sharedValidatorSet)
+	if !ok {
+		return s.LoadValidatorSet(ctx, sublogger)
+	}
+	shared.once.Do(func() {
+		shared.set = s.LoadValidatorSet(ctx, sublogger)
+	})
+	return shared.set
+}
+
+// SigningInfo returns the signing info of consAddress from the set, querying it when the set
+// does not have it.
+func (s *Service) SigningInfo(ctx context.Context, vs *ValidatorSet, consAddress string) (slashingtypes.ValidatorSigningInfo, error) {
+	if signingInfo, ok := vs.SigningInfos[consAddress]; ok {
+		return signingInfo, nil
+	}
+
+	slashingClient := slashingtypes.NewQueryClient(s.GrpcConn)
+	slashingRes, err := slashingClient.SigningInfo(
+		ctx,
+		&slashingtypes.QuerySigningInfoRequest{ConsAddress: consAddress},
+	)
+	if err != nil {
+		return slashingtypes.ValidatorSigningInfo{}, err
+	}
+	return slashingRes.ValSigningInfo, nil
+}
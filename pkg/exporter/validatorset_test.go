@@ -0,0 +1,219 @@
+package exporter
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"cosmossdk.io/math"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+// fakeStaking serves the validators, delegations and staking params of a fake chain.
+type fakeStaking struct {
+	stakingtypes.UnimplementedQueryServer
+
+	validators    []stakingtypes.Validator
+	maxValidators uint32
+	bondDenom     string
+	delegations   []stakingtypes.DelegationResponse
+	calls         atomic.Int32
+}
+
+func (f *fakeStaking) Validators(_ context.Context, _ *stakingtypes.QueryValidatorsRequest) (*stakingtypes.QueryValidatorsResponse, error) {
+	f.calls.Add(1)
+	return &stakingtypes.QueryValidatorsResponse{Validators: f.validators}, nil
+}
+
+func (f *fakeStaking) Delegation(_ context.Context, req *stakingtypes.QueryDelegationRequest) (*stakingtypes.QueryDelegationResponse, error) {
+	for _, delegation := range f.delegations {
+		if delegation.Delegation.DelegatorAddress == req.DelegatorAddr && delegation.Delegation.ValidatorAddress == req.ValidatorAddr {
+			return &stakingtypes.QueryDelegationResponse{DelegationResponse: &delegation}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "no delegation")
+}
+
+func (f *fakeStaking) Params(_ context.Context, _ *stakingtypes.QueryParamsRequest) (*stakingtypes.QueryParamsResponse, error) {
+	return &stakingtypes.QueryParamsResponse{Params: stakingtypes.Params{MaxValidators: f.maxValidators, BondDenom: f.bondDenom}}, nil
+}
+
+// fakeSlashing serves the signing infos of a fake chain.
+type fakeSlashing struct {
+	slashingtypes.UnimplementedQueryServer
+
+	signingInfos []slashingtypes.ValidatorSigningInfo
+	params       slashingtypes.Params
+	// unlisted are only returned by SigningInfo, as if SigningInfos had been capped
+	unlisted []slashingtypes.ValidatorSigningInfo
+}
+
+func (f *fakeSlashing) SigningInfos(_ context.Context, _ *slashingtypes.QuerySigningInfosRequest) (*slashingtypes.QuerySigningInfosResponse, error) {
+	return &slashingtypes.QuerySigningInfosResponse{Info: f.signingInfos}, nil
+}
+
+func (f *fakeSlashing) SigningInfo(_ context.Context, req *slashingtypes.QuerySigningInfoRequest) (*slashingtypes.QuerySigningInfoResponse, error) {
+	for _, info := range append(f.signingInfos, f.unlisted...) {
+		if info.Address == req.ConsAddress {
+			return &slashingtypes.QuerySigningInfoResponse{ValSigningInfo: info}, nil
+		}
+	}
+	return nil, slashingtypes.ErrNoSigningInfoFound
+}
+
+func (f *fakeSlashing) Params(_ context.Context, _ *slashingtypes.QueryParamsRequest) (*slashingtypes.QueryParamsResponse, error) {
+	return &slashingtypes.QueryParamsResponse{Params: f.params}, nil
+}
+
+// startFakeChain serves the given query servers and returns a service connected to them.
+func startFakeChain(t *testing.T, register func(server *grpc.Server)) *Service {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	register(server)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	config := &ServiceConfig{
+		Limit:               100,
+		ValidatorPrefix:     "cosmosvaloper",
+		ConsensusNodePrefix: "cosmosvalcons",
+		AccountPrefix:       "cosmos",
+	}
+	return &Service{Log: zerolog.Nop(), Config: config, GrpcConn: conn}
+}
+
+// fakeValidator returns a validator with a fresh consensus key and its consensus address.
+func fakeValidator(t *testing.T, operator string, shares int64, status stakingtypes.BondStatus, jailed bool) (stakingtypes.Validator, sdk.ConsAddress) {
+	t.Helper()
+
+	pubKey := ed25519.GenPrivKey().PubKey()
+	pubKeyAny, err := codectypes.NewAnyWithValue(pubKey)
+	require.NoError(t, err)
+
+	return stakingtypes.Validator{
+		OperatorAddress: operator,
+		ConsensusPubkey: pubKeyAny,
+		Jailed:          jailed,
+		Status:          status,
+		Tokens:          math.NewInt(shares),
+		DelegatorShares: math.LegacyNewDec(shares),
+		Description:     stakingtypes.Description{Moniker: operator},
+	}, sdk.ConsAddress(pubKey.Address())
+}
+
+func TestValidatorSet(t *testing.T) {
+	first, firstCons := fakeValidator(t, "first", 300, stakingtypes.Bonded, false)
+	second, _ := fakeValidator(t, "second", 100, stakingtypes.Bonded, false)
+	third, thirdCons := fakeValidator(t, "third", 50, stakingtypes.Bonded, false)
+	jailed, _ := fakeValidator(t, "jailed", 500, stakingtypes.Unbonding, true)
+
+	staking := &fakeStaking{
+		validators:    []stakingtypes.Validator{jailed, second, third, first},
+		maxValidators: 2,
+	}
+	slashing := &fakeSlashing{
+		signingInfos: []slashingtypes.ValidatorSigningInfo{{Address: firstCons.String(), MissedBlocksCounter: 3}},
+		unlisted:     []slashingtypes.ValidatorSigningInfo{{Address: thirdCons.String(), MissedBlocksCounter: 7}},
+	}
+	s := startFakeChain(t, func(server *grpc.Server) {
+		stakingtypes.RegisterQueryServer(server, staking)
+		slashingtypes.RegisterQueryServer(server, slashing)
+	})
+	sublogger := s.Log
+
+	ctx := WithValidatorSet(context.Background())
+	validatorSet := s.ValidatorSet(ctx, &sublogger)
+	require.Same(t, validatorSet, s.ValidatorSet(WithValidatorSet(ctx), &sublogger))
+	require.Equal(t, int32(1), staking.calls.Load())
+
+	var ranked []string
+	for _, validator := range validatorSet.Validators {
+		ranked = append(ranked, validator.OperatorAddress)
+	}
+	require.Equal(t, []string{"first", "second", "third", "jailed"}, ranked)
+	require.Equal(t, 1, validatorSet.Rank("first"))
+	require.Equal(t, 0, validatorSet.Rank("unknown"))
+	require.True(t, validatorSet.Active("second"))
+	require.False(t, validatorSet.Active("third"))
+	require.False(t, validatorSet.Active("jailed"))
+	require.Equal(t, firstCons, validatorSet.ConsAddress("first"))
+
+	signingInfo, err := s.SigningInfo(ctx, validatorSet, firstCons.String())
+	require.NoError(t, err)
+	require.Equal(t, int64(3), signingInfo.MissedBlocksCounter)
+
+	// missing from the set, queried on its own
+	signingInfo, err = s.SigningInfo(ctx, validatorSet, thirdCons.String())
+	require.NoError(t, err)
+	require.Equal(t, int64(7), signingInfo.MissedBlocksCounter)
+
+	// without a shared set every call loads its own
+	s.ValidatorSet(context.Background(), &sublogger)
+	require.Equal(t, int32(2), staking.calls.Load())
+}
+
+func TestValidatorSetStakePosition(t *testing.T) {
+	first, _ := fakeValidator(t, "first", 300, stakingtypes.Bonded, false)
+	second, _ := fakeValidator(t, "second", 100, stakingtypes.Bonded, false)
+	third, _ := fakeValidator(t, "third", 50, stakingtypes.Bonded, false)
+	jailed, _ := fakeValidator(t, "jailed", 500, stakingtypes.Unbonding, true)
+
+	validators := []stakingtypes.Validator{jailed, third, second, first}
+	sortValidators(validators)
+	validatorSet := NewValidatorSet(validators, nil, 2)
+
+	require.Equal(t, float64(450), validatorSet.BondedTokens())
+
+	share, cumulative := validatorSet.VotingPowerShare("second")
+	require.InDelta(t, 100.0/450, share, 1e-9)
+	require.InDelta(t, 400.0/450, cumulative, 1e-9)
+
+	share, cumulative = validatorSet.VotingPowerShare("jailed")
+	require.Zero(t, share)
+	require.Zero(t, cumulative)
+
+	lastActive, ok := validatorSet.LastActive()
+	require.True(t, ok)
+	require.Equal(t, "second", lastActive.OperatorAddress)
+
+	// the first validator alone holds more than a third
+	require.Equal(t, 1, validatorSet.NakamotoCoefficient())
+
+	// evenly spread, it takes two out of four
+	validators = nil
+	for _, operator := range []string{"a", "b", "c", "d"} {
+		validator, _ := fakeValidator(t, operator, 100, stakingtypes.Bonded, false)
+		validators = append(validators, validator)
+	}
+	require.Equal(t, 2, NewValidatorSet(validators, nil, 4).NakamotoCoefficient())
+	require.Zero(t, NewValidatorSet(nil, nil, 4).NakamotoCoefficient())
+
+	// slashed, its shares are worth fewer tokens and it ranks by the tokens
+	slashed, _ := fakeValidator(t, "slashed", 200, stakingtypes.Bonded, false)
+	slashed.Tokens = math.NewInt(80)
+	validators = []stakingtypes.Validator{slashed, second}
+	sortValidators(validators)
+	require.Equal(t, "second", validators[0].OperatorAddress)
+	require.Equal(t, "slashed", validators[1].OperatorAddress)
+}
@@ -13,19 +13,22 @@ import (
 	"github.com/rs/zerolog"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 )
 
 type WalletMetrics struct {
-	balanceGauge *prometheus.GaugeVec
+	balanceGauge    *prometheus.GaugeVec
+	balanceUSDGauge *prometheus.GaugeVec
 }
 type WalletExtendedMetrics struct {
 	delegationGauge   *prometheus.GaugeVec
 	redelegationGauge *prometheus.GaugeVec
 	unbondingsGauge   *prometheus.GaugeVec
 	rewardsGauge      *prometheus.GaugeVec
+	rewardsUSDGauge   *prometheus.GaugeVec
 }
 
 func NewWalletMetrics(reg prometheus.Registerer, config *ServiceConfig) *WalletMetrics {
@@ -36,10 +39,21 @@ func NewWalletMetrics(reg prometheus.Registerer, config *ServiceConfig) *WalletM
 				Help:        "Balance of the Cosmos-based blockchain wallet",
 				ConstLabels: config.ConstLabels,
 			},
+			[]string{"address", "denom", "display_denom", "base_denom", "trace_path"},
+		),
+		balanceUSDGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_wallet_balance_usd",
+				Help:        "Balance of the Cosmos-based blockchain wallet in USD",
+				ConstLabels: config.ConstLabels,
+			},
 			[]string{"address", "denom"},
 		),
 	}
 	reg.MustRegister(m.balanceGauge)
+	if config.TokenPrice {
+		reg.MustRegister(m.balanceUSDGauge)
+	}
 
 	return m
 }
@@ -79,6 +93,15 @@ func NewWalletExtendedMetrics(reg prometheus.Registerer, config *ServiceConfig)
 				Help:        "Rewards of the Cosmos-based blockchain wallet",
 				ConstLabels: config.ConstLabels,
 			},
+			[]string{"address", "denom", "display_denom", "validator_address"},
+		),
+
+		rewardsUSDGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "cosmos_wallet_rewards_usd",
+				Help:        "Rewards of the Cosmos-based blockchain wallet in USD",
+				ConstLabels: config.ConstLabels,
+			},
 			[]string{"address", "denom", "validator_address"},
 		),
 	}
@@ -87,126 +110,163 @@ func NewWalletExtendedMetrics(reg prometheus.Registerer, config *ServiceConfig)
 	reg.MustRegister(m.unbondingsGauge)
 	reg.MustRegister(m.redelegationGauge)
 	reg.MustRegister(m.rewardsGauge)
+	if config.TokenPrice {
+		reg.MustRegister(m.rewardsUSDGauge)
+	}
 
 	return m
 }
 
-func GetWalletMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *WalletMetrics, s *Service, config *ServiceConfig, address sdk.AccAddress, allBalances bool) {
+func GetWalletMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *WalletMetrics, s *Service, config *ServiceConfig, prices map[string]float64, address sdk.AccAddress, allBalances bool) {
+	walletAddress := config.AccAddressString(address)
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		sublogger.Debug().
-			Str("address", address.String()).
+			Str("address", walletAddress).
 			Msg("Started querying balance")
 		queryStart := time.Now()
 
 		bankClient := banktypes.NewQueryClient(s.GrpcConn)
 
 		if allBalances {
-			bankRes, err := bankClient.AllBalances(
-				context.Background(),
-				&banktypes.QueryAllBalancesRequest{Address: address.String()},
-			)
+			balances, err := Paginate(ctx, s, "bank.AllBalances",
+				func(ctx context.Context, page *querytypes.PageRequest) ([]sdk.Coin, *querytypes.PageResponse, error) {
+					res, err := bankClient.AllBalances(
+						ctx,
+						&banktypes.QueryAllBalancesRequest{Address: walletAddress, Pagination: page},
+					)
+					return res.GetBalances(), res.GetPagination(), err
+				})
 			if err != nil {
 				sublogger.Error().
-					Str("address", address.String()).
+					Str("address", walletAddress).
 					Err(err).
 					Msg("Could not get balance")
 				return
 			}
 
 			sublogger.Debug().
-				Str("address", address.String()).
+				Str("address", walletAddress).
 				Float64("request-time", time.Since(queryStart).Seconds()).
 				Msg("Finished querying all balances")
 
-			for _, balance := range bankRes.Balances {
-				// because cosmos dec doesn't have .toFloat64() method or whatever and returns everything as int
-				if value, err := strconv.ParseFloat(balance.Amount.String(), 64); err != nil {
+			denoms := make([]string, 0, len(balances))
+			for _, balance := range balances {
+				denoms = append(denoms, balance.Denom)
+			}
+			s.ResolveDenomTraces(ctx, denoms)
+
+			for _, balance := range balances {
+				if value, unit, err := s.Denoms.Value(balance.Denom, balance.Amount.String()); err != nil {
 					sublogger.Error().
-						Str("address", address.String()).
+						Str("address", walletAddress).
 						Err(err).
 						Msg("Could not parse balance")
 				} else {
+					baseDenom, tracePath := s.DenomTrace(ctx, balance.Denom)
 					metrics.balanceGauge.With(prometheus.Labels{
-						"address": address.String(),
+						"address":       walletAddress,
+						"denom":         balance.Denom,
+						"display_denom": unit.Display,
+						"base_denom":    baseDenom,
+						"trace_path":    tracePath,
+					}).Set(value)
+					setUSD(metrics.balanceUSDGauge, prometheus.Labels{
+						"address": walletAddress,
 						"denom":   balance.Denom,
-					}).Set(value / config.DenomCoefficient)
+					}, prices, balance.Denom, value)
 				}
 			}
 		} else {
+			// --denom is the display unit of the staking denom
+			denom := s.Denoms.Base(config.Denom)
 			bankRes, err := bankClient.Balance(
-				context.Background(),
-				&banktypes.QueryBalanceRequest{Address: address.String(), Denom: config.Denom},
+				ctx,
+				&banktypes.QueryBalanceRequest{Address: walletAddress, Denom: denom},
 			)
 			if err != nil {
 				sublogger.Error().
-					Str("address", address.String()).
+					Str("address", walletAddress).
 					Err(err).
 					Msg("Could not get balance")
 				return
 			}
 
 			sublogger.Debug().
-				Str("address", address.String()).
-				Str("denom", config.Denom).
+				Str("address", walletAddress).
+				Str("denom", denom).
 				Float64("request-time", time.Since(queryStart).Seconds()).
 				Msg("Finished querying balance")
 			balance := bankRes.Balance
 
-			// because cosmos dec doesn't have .toFloat64() method or whatever and returns everything as int
-			if value, err := strconv.ParseFloat(balance.Amount.String(), 64); err != nil {
+			if value, unit, err := s.Denoms.Value(balance.Denom, balance.Amount.String()); err != nil {
 				sublogger.Error().
-					Str("address", address.String()).
+					Str("address", walletAddress).
 					Err(err).
 					Msg("Could not parse balance")
 			} else {
+				baseDenom, tracePath := s.DenomTrace(ctx, balance.Denom)
 				metrics.balanceGauge.With(prometheus.Labels{
-					"address": address.String(),
+					"address":       walletAddress,
+					"denom":         balance.Denom,
+					"display_denom": unit.Display,
+					"base_denom":    baseDenom,
+					"trace_path":    tracePath,
+				}).Set(value)
+				setUSD(metrics.balanceUSDGauge, prometheus.Labels{
+					"address": walletAddress,
 					"denom":   balance.Denom,
-				}).Set(value / config.DenomCoefficient)
+				}, prices, balance.Denom, value)
 			}
 		}
 	}()
 }
 
-func getWalletExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *WalletExtendedMetrics, s *Service, config *ServiceConfig, address sdk.AccAddress) {
+func getWalletExtendedMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *WalletExtendedMetrics, s *Service, config *ServiceConfig, prices map[string]float64, address sdk.AccAddress) {
+	walletAddress := config.AccAddressString(address)
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		sublogger.Debug().
-			Str("address", address.String()).
+			Str("address", walletAddress).
 			Msg("Started querying delegations")
 		queryStart := time.Now()
 
 		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-		stakingRes, err := stakingClient.DelegatorDelegations(
-			context.Background(),
-			&stakingtypes.QueryDelegatorDelegationsRequest{DelegatorAddr: address.String()},
-		)
+		delegations, err := Paginate(ctx, s, "staking.DelegatorDelegations",
+			func(ctx context.Context, page *querytypes.PageRequest) ([]stakingtypes.DelegationResponse, *querytypes.PageResponse, error) {
+				res, err := stakingClient.DelegatorDelegations(
+					ctx,
+					&stakingtypes.QueryDelegatorDelegationsRequest{DelegatorAddr: walletAddress, Pagination: page},
+				)
+				return res.GetDelegationResponses(), res.GetPagination(), err
+			})
 		if err != nil {
 			sublogger.Error().
-				Str("address", address.String()).
+				Str("address", walletAddress).
 				Err(err).
 				Msg("Could not get delegations")
 			return
 		}
 
 		sublogger.Debug().
-			Str("address", address.String()).
+			Str("address", walletAddress).
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying delegations")
 
-		for _, delegation := range stakingRes.DelegationResponses {
+		for _, delegation := range delegations {
 			// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
 			if value, err := strconv.ParseFloat(delegation.Balance.Amount.String(), 64); err != nil {
 				sublogger.Error().
-					Str("address", address.String()).
+					Str("address", walletAddress).
 					Err(err).
 					Msg("Could not get delegation")
 			} else {
 				metrics.delegationGauge.With(prometheus.Labels{
-					"address":      address.String(),
+					"address":      walletAddress,
 					"denom":        config.Denom,
 					"delegated_to": delegation.Delegation.ValidatorAddress,
 				}).Set(value / config.DenomCoefficient)
@@ -218,35 +278,39 @@ func getWalletExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, met
 	go func() {
 		defer wg.Done()
 		sublogger.Debug().
-			Str("address", address.String()).
+			Str("address", walletAddress).
 			Msg("Started querying unbonding delegations")
 		queryStart := time.Now()
 
 		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-		stakingRes, err := stakingClient.DelegatorUnbondingDelegations(
-			context.Background(),
-			&stakingtypes.QueryDelegatorUnbondingDelegationsRequest{DelegatorAddr: address.String()},
-		)
+		unbondings, err := Paginate(ctx, s, "staking.DelegatorUnbondingDelegations",
+			func(ctx context.Context, page *querytypes.PageRequest) ([]stakingtypes.UnbondingDelegation, *querytypes.PageResponse, error) {
+				res, err := stakingClient.DelegatorUnbondingDelegations(
+					ctx,
+					&stakingtypes.QueryDelegatorUnbondingDelegationsRequest{DelegatorAddr: walletAddress, Pagination: page},
+				)
+				return res.GetUnbondingResponses(), res.GetPagination(), err
+			})
 		if err != nil {
 			sublogger.Error().
-				Str("address", address.String()).
+				Str("address", walletAddress).
 				Err(err).
 				Msg("Could not get unbonding delegations")
 			return
 		}
 
 		sublogger.Debug().
-			Str("address", address.String()).
+			Str("address", walletAddress).
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying unbonding delegations")
 
-		for _, unbonding := range stakingRes.UnbondingResponses {
+		for _, unbonding := range unbondings {
 			var sum float64
 			for _, entry := range unbonding.Entries {
 				// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
 				if value, err := strconv.ParseFloat(entry.Balance.String(), 64); err != nil {
 					sublogger.Error().
-						Str("address", address.String()).
+						Str("address", walletAddress).
 						Err(err).
 						Msg("Could not parse unbonding delegation")
 				} else {
@@ -266,35 +330,39 @@ func getWalletExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, met
 	go func() {
 		defer wg.Done()
 		sublogger.Debug().
-			Str("address", address.String()).
+			Str("address", walletAddress).
 			Msg("Started querying redelegations")
 		queryStart := time.Now()
 
 		stakingClient := stakingtypes.NewQueryClient(s.GrpcConn)
-		stakingRes, err := stakingClient.Redelegations(
-			context.Background(),
-			&stakingtypes.QueryRedelegationsRequest{DelegatorAddr: address.String()},
-		)
+		redelegations, err := Paginate(ctx, s, "staking.Redelegations",
+			func(ctx context.Context, page *querytypes.PageRequest) ([]stakingtypes.RedelegationResponse, *querytypes.PageResponse, error) {
+				res, err := stakingClient.Redelegations(
+					ctx,
+					&stakingtypes.QueryRedelegationsRequest{DelegatorAddr: walletAddress, Pagination: page},
+				)
+				return res.GetRedelegationResponses(), res.GetPagination(), err
+			})
 		if err != nil {
 			sublogger.Error().
-				Str("address", address.String()).
+				Str("address", walletAddress).
 				Err(err).
 				Msg("Could not get redelegations")
 			return
 		}
 
 		sublogger.Debug().
-			Str("address", address.String()).
+			Str("address", walletAddress).
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying redelegations")
 
-		for _, redelegation := range stakingRes.RedelegationResponses {
+		for _, redelegation := range redelegations {
 			var sum float64
 			for _, entry := range redelegation.Entries {
 				// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
 				if value, err := strconv.ParseFloat(entry.Balance.String(), 64); err != nil {
 					sublogger.Error().
-						Str("address", address.String()).
+						Str("address", walletAddress).
 						Err(err).
 						Msg("Could not parse redelegation")
 				} else {
@@ -316,41 +384,46 @@ func getWalletExtendedMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, met
 		defer wg.Done()
 
 		sublogger.Debug().
-			Str("address", address.String()).
+			Str("address", walletAddress).
 			Msg("Started querying rewards")
 		queryStart := time.Now()
 
 		distributionClient := distributiontypes.NewQueryClient(s.GrpcConn)
 		distributionRes, err := distributionClient.DelegationTotalRewards(
-			context.Background(),
-			&distributiontypes.QueryDelegationTotalRewardsRequest{DelegatorAddress: address.String()},
+			ctx,
+			&distributiontypes.QueryDelegationTotalRewardsRequest{DelegatorAddress: walletAddress},
 		)
 		if err != nil {
 			sublogger.Error().
-				Str("address", address.String()).
+				Str("address", walletAddress).
 				Err(err).
 				Msg("Could not get rewards")
 			return
 		}
 		sublogger.Debug().
-			Str("address", address.String()).
+			Str("address", walletAddress).
 			Float64("request-time", time.Since(queryStart).Seconds()).
 			Msg("Finished querying rewards")
 
 		for _, reward := range distributionRes.Rewards {
 			for _, entry := range reward.Reward {
-				// because cosmos's dec doesn't have .toFloat64() method or whatever and returns everything as int
-				if value, err := strconv.ParseFloat(entry.Amount.String(), 64); err != nil {
+				if value, unit, err := s.Denoms.Value(entry.Denom, entry.Amount.String()); err != nil {
 					sublogger.Error().
-						Str("address", address.String()).
+						Str("address", walletAddress).
 						Err(err).
 						Msg("Could not parse reward")
 				} else {
 					metrics.rewardsGauge.With(prometheus.Labels{
-						"address":           address.String(),
-						"denom":             config.Denom,
+						"address":           walletAddress,
+						"denom":             entry.Denom,
+						"display_denom":     unit.Display,
 						"validator_address": reward.ValidatorAddress,
-					}).Set(value / config.DenomCoefficient)
+					}).Set(value)
+					setUSD(metrics.rewardsUSDGauge, prometheus.Labels{
+						"address":           walletAddress,
+						"denom":             entry.Denom,
+						"validator_address": reward.ValidatorAddress,
+					}, prices, entry.Denom, value)
 				}
 			}
 		}
@@ -365,7 +438,7 @@ func (s *Service) WalletHandler(w http.ResponseWriter, r *http.Request) {
 		Logger()
 
 	address := r.URL.Query().Get("address")
-	myAddress, err := sdk.AccAddressFromBech32(address)
+	myAddress, err := s.Config.AccAddressFromBech32(address)
 	if err != nil {
 		sublogger.Error().
 			Str("address", address).
@@ -378,12 +451,19 @@ func (s *Service) WalletHandler(w http.ResponseWriter, r *http.Request) {
 	walletMetrics := NewWalletMetrics(registry, s.Config)
 	walletExtendedMetrics := NewWalletExtendedMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "wallet")
 	var wg sync.WaitGroup
-	GetWalletMetrics(&wg, &sublogger, walletMetrics, s, s.Config, myAddress, true)
-	getWalletExtendedMetrics(&wg, &sublogger, walletExtendedMetrics, s, s.Config, myAddress)
+	// fetched once, for every value of the scrape
+	prices := s.PricesUSD(ctx, &sublogger)
+	GetWalletMetrics(ctx, &wg, &sublogger, walletMetrics, s, s.Config, prices, myAddress, true)
+	getWalletExtendedMetrics(ctx, &wg, &sublogger, walletExtendedMetrics, s, s.Config, prices, myAddress)
 	wg.Wait()
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	s.FinishCollector(run)
+
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
@@ -0,0 +1,22 @@
+// Package extensions lists the chain extensions compiled into the exporter.
+package extensions
+
+import (
+	"github.com/pfc-developer/cosmos-exporter/pkg/exporter"
+	"github.com/pfc-developer/cosmos-exporter/pkg/extensions/initia"
+	"github.com/pfc-developer/cosmos-exporter/pkg/extensions/injective"
+	"github.com/pfc-developer/cosmos-exporter/pkg/extensions/kujira"
+	"github.com/pfc-developer/cosmos-exporter/pkg/extensions/pryzm"
+	"github.com/pfc-developer/cosmos-exporter/pkg/extensions/sei"
+)
+
+// All returns one instance of every available extension.
+func All() []exporter.ChainExtension {
+	return []exporter.ChainExtension{
+		initia.New(),
+		injective.New(),
+		kujira.New(),
+		pryzm.New(),
+		sei.New(),
+	}
+}
@@ -1,8 +1,8 @@
 // original here - https://gist.github.com/jumanzii/031cfea1b2aa3c2a43b63aa62a919285
-package main
+package initia
 
 import (
-	//"context"
+	"context"
 	//oracletypes "github.com/Team-Kujira/core/x/oracle/types"
 	"net/http"
 	"sync"
@@ -12,12 +12,41 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/pfc-developer/cosmos-exporter/pkg/exporter"
 )
 
+// Extension switches the core collectors to the initia modules, which replace staking.
+// The validators are then matched with --validatorcons.
+type Extension struct{}
+
+func New() *Extension {
+	return &Extension{}
+}
+
+func (e *Extension) Name() string {
+	return "initia"
+}
+
+func (e *Extension) RegisterFlags(_ *pflag.FlagSet) {}
+
+func (e *Extension) Configure(config *exporter.ServiceConfig) {
+	config.SetIsInitia(true)
+}
+
+func (e *Extension) RegisterMetrics(reg prometheus.Registerer, config *exporter.ServiceConfig) exporter.ExtensionMetrics {
+	return NewInitiaMetrics(reg, config)
+}
+
+func (e *Extension) Routes(s *exporter.Service) map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"/initia": func(w http.ResponseWriter, r *http.Request) { InitiaMetricHandler(w, r, s) },
+	}
+}
+
 /*
 	type voteMissCounter struct {
 		MissCount string `json:"miss_count"`
@@ -46,7 +75,19 @@ func NewInitiaMetrics(reg prometheus.Registerer, config *exporter.ServiceConfig)
 	return m
 }
 
-func getInitiaMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *InitiaMetrics, s *exporter.Service, _ *exporter.ServiceConfig, validatorAddress sdk.ValAddress) {
+func (m *InitiaMetrics) CollectChain(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service) {
+}
+
+func (m *InitiaMetrics) CollectValidator(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, s *exporter.Service, validator sdk.ValAddress) {
+	if s.Oracle {
+		getInitiaMetrics(ctx, wg, sublogger, m, s, s.Config, validator)
+	}
+}
+
+func (m *InitiaMetrics) CollectWallet(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service, _ sdk.AccAddress) {
+}
+
+func getInitiaMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *InitiaMetrics, s *exporter.Service, _ *exporter.ServiceConfig, validatorAddress sdk.ValAddress) {
 	/*
 		wg.Add(1)
 
@@ -56,7 +97,7 @@ func getInitiaMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *In
 			queryStart := time.Now()
 
 			oracleClient := oracletypes.NewQueryClient(s.GrpcConn)
-			response, err := oracleClient.MissCounter(context.Background(), &oracletypes.QueryMissCounterRequest{ValidatorAddr: validatorAddress.String()})
+			response, err := oracleClient.MissCounter(ctx, &oracletypes.QueryMissCounterRequest{ValidatorAddr: validatorAddress.String()})
 			if err != nil {
 				sublogger.Error().
 					Err(err).
@@ -84,7 +125,7 @@ func InitiaMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Ser
 		Logger()
 
 	address := r.URL.Query().Get("address")
-	myAddress, err := sdk.ValAddressFromBech32(address)
+	myAddress, err := s.Config.ValAddressFromBech32(address)
 	if err != nil {
 		sublogger.Error().
 			Str("address", address).
@@ -95,12 +136,17 @@ func InitiaMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Ser
 	registry := prometheus.NewRegistry()
 	intiaMetrics := NewInitiaMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "initia")
+
 	var wg sync.WaitGroup
-	getInitiaMetrics(&wg, &sublogger, intiaMetrics, s, s.Config, myAddress)
+	getInitiaMetrics(ctx, &wg, &sublogger, intiaMetrics, s, s.Config, myAddress)
 
 	wg.Wait()
+	s.FinishCollector(run)
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
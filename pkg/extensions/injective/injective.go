@@ -1,7 +1,8 @@
 // original here - https://gist.github.com/jumanzii/031cfea1b2aa3c2a43b63aa62a919285
-package main
+package injective
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,12 +14,72 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/pfc-developer/cosmos-exporter/pkg/exporter"
 )
 
+// Extension reports the state of the peggy bridge and of an orchestrator, read from the LCD.
+type Extension struct {
+	Peggo        bool
+	Orchestrator string
+}
+
+func New() *Extension {
+	return &Extension{}
+}
+
+func (e *Extension) Name() string {
+	return "injective"
+}
+
+func (e *Extension) RegisterFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&e.Peggo, "peggo", false, "serve peggo info in the single call to /metrics (injective extension)")
+	flags.StringVar(&e.Orchestrator, "orchestrator", "", "orchestrator wallet (injective extension)")
+}
+
+func (e *Extension) Configure(_ *exporter.ServiceConfig) {}
+
+func (e *Extension) RegisterMetrics(reg prometheus.Registerer, config *exporter.ServiceConfig) exporter.ExtensionMetrics {
+	return &extensionMetrics{InjMetrics: NewInjMetrics(reg, config), extension: e}
+}
+
+func (e *Extension) Routes(s *exporter.Service) map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"/injective": func(w http.ResponseWriter, r *http.Request) { InjMetricHandler(w, r, s) },
+	}
+}
+
+// extensionMetrics collects the peggy metrics of the configured orchestrator in single mode.
+type extensionMetrics struct {
+	*InjMetrics
+	extension *Extension
+}
+
+func (m *extensionMetrics) CollectChain(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, s *exporter.Service) {
+	if !m.extension.Peggo || m.extension.Orchestrator == "" {
+		return
+	}
+
+	accAddress, err := s.Config.AccAddressFromBech32(m.extension.Orchestrator)
+	if err != nil {
+		sublogger.Error().
+			Str("address", m.extension.Orchestrator).
+			Err(err).
+			Msg("doesn't appear valid")
+		return
+	}
+	doInjMetrics(ctx, wg, sublogger, m.InjMetrics, s, s.Config, accAddress)
+}
+
+func (m *extensionMetrics) CollectValidator(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service, _ sdk.ValAddress) {
+}
+
+func (m *extensionMetrics) CollectWallet(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service, _ sdk.AccAddress) {
+}
+
 /*
 	type voteMissCounter struct {
 		MissCount string `json:"miss_count"`
@@ -70,21 +131,29 @@ type LastClaimEvent struct {
 	EventHeight string `json:"ethereum_event_height"`
 }
 
-func doInjMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *InjMetrics, _ *exporter.Service, _ *exporter.ServiceConfig, orchestratorAddress sdk.AccAddress) {
+func doInjMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *InjMetrics, _ *exporter.Service, config *exporter.ServiceConfig, orchestratorAddress sdk.AccAddress) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		sublogger.Debug().Msg("Started querying LCD peggy module state")
 		queryStart := time.Now()
 
-		requestURL := fmt.Sprintf("%s/peggy/v1/module_state", LCD)
-		response, err := http.Get(requestURL) // #nosec
+		requestURL := fmt.Sprintf("%s/peggy/v1/module_state", config.LCD)
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not create LCD request")
+			return
+		}
+		response, err := http.DefaultClient.Do(request) // #nosec
 		if err != nil {
 			sublogger.Error().
 				Err(err).
 				Msg("Could not get peggy module state")
 			return
 		}
+		defer response.Body.Close()
 		moduleStateResponse := &ModuleStateResponse{}
 		err = json.NewDecoder(response.Body).Decode(moduleStateResponse)
 		if err != nil {
@@ -114,14 +183,22 @@ func doInjMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *InjMet
 		sublogger.Debug().Msg("Started querying LCD peggy oracle event for orchestrator")
 		queryStart := time.Now()
 
-		requestURL := fmt.Sprintf("%s/peggy/v1/oracle/event/%s", LCD, orchestratorAddress.String())
-		response, err := http.Get(requestURL) // #nosec
+		requestURL := fmt.Sprintf("%s/peggy/v1/oracle/event/%s", config.LCD, config.AccAddressString(orchestratorAddress))
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not create LCD request")
+			return
+		}
+		response, err := http.DefaultClient.Do(request) // #nosec
 		if err != nil {
 			sublogger.Error().
 				Err(err).
 				Msg("Could not get peggy oracle event for orchestrator")
 			return
 		}
+		defer response.Body.Close()
 		eventStateResponse := &LastClaimEventResponse{}
 		err = json.NewDecoder(response.Body).Decode(eventStateResponse)
 		if err != nil {
@@ -162,7 +239,7 @@ func InjMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Servic
 		Logger()
 
 	address := r.URL.Query().Get("address")
-	myAddress, err := sdk.AccAddressFromBech32(address)
+	myAddress, err := s.Config.AccAddressFromBech32(address)
 	if err != nil {
 		sublogger.Error().
 			Str("address", address).
@@ -173,13 +250,18 @@ func InjMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Servic
 	registry := prometheus.NewRegistry()
 	injMetrics := NewInjMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "injective")
+
 	var wg sync.WaitGroup
 
-	doInjMetrics(&wg, &sublogger, injMetrics, s, s.Config, myAddress)
+	doInjMetrics(ctx, &wg, &sublogger, injMetrics, s, s.Config, myAddress)
 
 	wg.Wait()
+	s.FinishCollector(run)
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
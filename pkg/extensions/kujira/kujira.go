@@ -1,5 +1,5 @@
 // original here - https://gist.github.com/jumanzii/031cfea1b2aa3c2a43b63aa62a919285
-package main
+package kujira
 
 import (
 	"context"
@@ -12,12 +12,38 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/pfc-developer/cosmos-exporter/pkg/exporter"
 )
 
+// Extension reports the oracle vote misses of the kujira validators.
+type Extension struct{}
+
+func New() *Extension {
+	return &Extension{}
+}
+
+func (e *Extension) Name() string {
+	return "kujira"
+}
+
+func (e *Extension) RegisterFlags(_ *pflag.FlagSet) {}
+
+func (e *Extension) Configure(_ *exporter.ServiceConfig) {}
+
+func (e *Extension) RegisterMetrics(reg prometheus.Registerer, config *exporter.ServiceConfig) exporter.ExtensionMetrics {
+	return NewKujiMetrics(reg, config)
+}
+
+func (e *Extension) Routes(s *exporter.Service) map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"/kujira": func(w http.ResponseWriter, r *http.Request) { KujiraMetricHandler(w, r, s) },
+	}
+}
+
 /*
 	type voteMissCounter struct {
 		MissCount string `json:"miss_count"`
@@ -44,7 +70,21 @@ func NewKujiMetrics(reg prometheus.Registerer, config *exporter.ServiceConfig) *
 	return m
 }
 
-func getKujiMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *KujiMetrics, s *exporter.Service, _ *exporter.ServiceConfig, validatorAddress sdk.ValAddress) {
+func (m *KujiMetrics) CollectChain(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service) {
+}
+
+func (m *KujiMetrics) CollectValidator(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, s *exporter.Service, validator sdk.ValAddress) {
+	if s.Oracle {
+		getKujiMetrics(ctx, wg, sublogger, m, s, s.Config, validator)
+	}
+}
+
+func (m *KujiMetrics) CollectWallet(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service, _ sdk.AccAddress) {
+}
+
+func getKujiMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *KujiMetrics, s *exporter.Service, config *exporter.ServiceConfig, validatorAddress sdk.ValAddress) {
+	operatorAddress := config.ValAddressString(validatorAddress)
+
 	wg.Add(1)
 
 	go func() {
@@ -53,7 +93,7 @@ func getKujiMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *Kuji
 		queryStart := time.Now()
 
 		oracleClient := oracletypes.NewQueryClient(s.GrpcConn)
-		response, err := oracleClient.MissCounter(context.Background(), &oracletypes.QueryMissCounterRequest{ValidatorAddr: validatorAddress.String()})
+		response, err := oracleClient.MissCounter(ctx, &oracletypes.QueryMissCounterRequest{ValidatorAddr: operatorAddress})
 		if err != nil {
 			sublogger.Error().
 				Err(err).
@@ -67,7 +107,7 @@ func getKujiMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *Kuji
 
 		missCount := float64(response.MissCounter)
 
-		metrics.votePenaltyCount.WithLabelValues("miss", validatorAddress.String()).Add(missCount)
+		metrics.votePenaltyCount.WithLabelValues("miss", operatorAddress).Add(missCount)
 	}()
 }
 
@@ -79,7 +119,7 @@ func KujiraMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Ser
 		Logger()
 
 	address := r.URL.Query().Get("address")
-	myAddress, err := sdk.ValAddressFromBech32(address)
+	myAddress, err := s.Config.ValAddressFromBech32(address)
 	if err != nil {
 		sublogger.Error().
 			Str("address", address).
@@ -90,12 +130,17 @@ func KujiraMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Ser
 	registry := prometheus.NewRegistry()
 	kujiMetrics := NewKujiMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "kujira")
+
 	var wg sync.WaitGroup
-	getKujiMetrics(&wg, &sublogger, kujiMetrics, s, s.Config, myAddress)
+	getKujiMetrics(ctx, &wg, &sublogger, kujiMetrics, s, s.Config, myAddress)
 
 	wg.Wait()
+	s.FinishCollector(run)
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
@@ -1,7 +1,8 @@
 // original here - https://gist.github.com/jumanzii/031cfea1b2aa3c2a43b63aa62a919285
-package main
+package pryzm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,12 +14,38 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/pfc-developer/cosmos-exporter/pkg/exporter"
 )
 
+// Extension reports the oracle feeder misses of the pryzm validators, read from the LCD.
+type Extension struct{}
+
+func New() *Extension {
+	return &Extension{}
+}
+
+func (e *Extension) Name() string {
+	return "pryzm"
+}
+
+func (e *Extension) RegisterFlags(_ *pflag.FlagSet) {}
+
+func (e *Extension) Configure(_ *exporter.ServiceConfig) {}
+
+func (e *Extension) RegisterMetrics(reg prometheus.Registerer, config *exporter.ServiceConfig) exporter.ExtensionMetrics {
+	return NewPryzmMetrics(reg, config)
+}
+
+func (e *Extension) Routes(s *exporter.Service) map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"/pryzm": func(w http.ResponseWriter, r *http.Request) { PryzmMetricHandler(w, r, s) },
+	}
+}
+
 /*
 	type voteMissCounter struct {
 		MissCount string `json:"miss_count"`
@@ -45,6 +72,18 @@ func NewPryzmMetrics(reg prometheus.Registerer, config *exporter.ServiceConfig)
 	return m
 }
 
+func (m *PryzmMetrics) CollectChain(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service) {
+}
+
+func (m *PryzmMetrics) CollectValidator(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, s *exporter.Service, validator sdk.ValAddress) {
+	if s.Oracle {
+		doPryzmMetrics(ctx, wg, sublogger, m, s, s.Config, validator)
+	}
+}
+
+func (m *PryzmMetrics) CollectWallet(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service, _ sdk.AccAddress) {
+}
+
 type MissCounterResponse struct {
 	Miss MissCounterM `json:"miss_counter"`
 }
@@ -54,21 +93,29 @@ type MissCounterM struct {
 	Counter   string `json:"counter"`
 }
 
-func doPryzmMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *PryzmMetrics, _ *exporter.Service, _ *exporter.ServiceConfig, validatorAddress sdk.ValAddress) {
+func doPryzmMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *PryzmMetrics, _ *exporter.Service, config *exporter.ServiceConfig, validatorAddress sdk.ValAddress) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		sublogger.Debug().Msg("Started querying LCD peggy module state")
 		queryStart := time.Now()
 
-		requestURL := fmt.Sprintf("%s/refractedlabs/oracle/v1/miss_counter/%s", LCD, validatorAddress.String())
-		response, err := http.Get(requestURL) // #nosec
+		requestURL := fmt.Sprintf("%s/refractedlabs/oracle/v1/miss_counter/%s", config.LCD, config.ValAddressString(validatorAddress))
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			sublogger.Error().
+				Err(err).
+				Msg("Could not create LCD request")
+			return
+		}
+		response, err := http.DefaultClient.Do(request) // #nosec
 		if err != nil {
 			sublogger.Error().
 				Err(err).
 				Msg("Could not get peggy module state")
 			return
 		}
+		defer response.Body.Close()
 		moduleStateResponse := &MissCounterResponse{}
 		err = json.NewDecoder(response.Body).Decode(moduleStateResponse)
 		if err != nil {
@@ -101,7 +148,7 @@ func PryzmMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Serv
 		Logger()
 
 	address := r.URL.Query().Get("validator")
-	myAddress, err := sdk.ValAddressFromBech32(address)
+	myAddress, err := s.Config.ValAddressFromBech32(address)
 	if err != nil {
 		sublogger.Error().
 			Str("address", address).
@@ -112,13 +159,18 @@ func PryzmMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Serv
 	registry := prometheus.NewRegistry()
 	pryzmMetrics := NewPryzmMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "pryzm")
+
 	var wg sync.WaitGroup
 
-	doPryzmMetrics(&wg, &sublogger, pryzmMetrics, s, s.Config, myAddress)
+	doPryzmMetrics(ctx, &wg, &sublogger, pryzmMetrics, s, s.Config, myAddress)
 
 	wg.Wait()
+	s.FinishCollector(run)
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
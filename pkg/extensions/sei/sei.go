@@ -1,5 +1,5 @@
 // original here - https://gist.github.com/jumanzii/031cfea1b2aa3c2a43b63aa62a919285
-package main
+package sei
 
 import (
 	"context"
@@ -11,13 +11,39 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
-	oracletypes "github.com/pfc-developer/cosmos-exporter/cmd/sei-cosmos-exporter/types"
 	"github.com/pfc-developer/cosmos-exporter/pkg/exporter"
+	oracletypes "github.com/pfc-developer/cosmos-exporter/pkg/extensions/sei/types"
 )
 
+// Extension reports the oracle vote penalty counters of the sei validators.
+type Extension struct{}
+
+func New() *Extension {
+	return &Extension{}
+}
+
+func (e *Extension) Name() string {
+	return "sei"
+}
+
+func (e *Extension) RegisterFlags(_ *pflag.FlagSet) {}
+
+func (e *Extension) Configure(_ *exporter.ServiceConfig) {}
+
+func (e *Extension) RegisterMetrics(reg prometheus.Registerer, config *exporter.ServiceConfig) exporter.ExtensionMetrics {
+	return NewSeiMetrics(reg, config)
+}
+
+func (e *Extension) Routes(s *exporter.Service) map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"/sei": func(w http.ResponseWriter, r *http.Request) { OracleMetricHandler(w, r, s, s.Config) },
+	}
+}
+
 /*
 	type votePenaltyCounter struct {
 		MissCount    string `json:"miss_count"`
@@ -46,7 +72,21 @@ func NewSeiMetrics(reg prometheus.Registerer, config *exporter.ServiceConfig) *S
 	return m
 }
 
-func getSeiMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *SeiMetrics, s *exporter.Service, _ *exporter.ServiceConfig, validatorAddress sdk.ValAddress) {
+func (m *SeiMetrics) CollectChain(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service) {
+}
+
+func (m *SeiMetrics) CollectValidator(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, s *exporter.Service, validator sdk.ValAddress) {
+	if s.Oracle {
+		getSeiMetrics(ctx, wg, sublogger, m, s, s.Config, validator)
+	}
+}
+
+func (m *SeiMetrics) CollectWallet(_ context.Context, _ *sync.WaitGroup, _ *zerolog.Logger, _ *exporter.Service, _ sdk.AccAddress) {
+}
+
+func getSeiMetrics(ctx context.Context, wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *SeiMetrics, s *exporter.Service, config *exporter.ServiceConfig, validatorAddress sdk.ValAddress) {
+	operatorAddress := config.ValAddressString(validatorAddress)
+
 	wg.Add(1)
 
 	go func() {
@@ -55,7 +95,7 @@ func getSeiMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *SeiMe
 		queryStart := time.Now()
 
 		oracleClient := oracletypes.NewQueryClient(s.GrpcConn)
-		response, err := oracleClient.VotePenaltyCounter(context.Background(), &oracletypes.QueryVotePenaltyCounterRequest{ValidatorAddr: validatorAddress.String()})
+		response, err := oracleClient.VotePenaltyCounter(ctx, &oracletypes.QueryVotePenaltyCounterRequest{ValidatorAddr: operatorAddress})
 		if err != nil {
 			sublogger.Error().
 				Err(err).
@@ -71,9 +111,9 @@ func getSeiMetrics(wg *sync.WaitGroup, sublogger *zerolog.Logger, metrics *SeiMe
 		abstainCount := float64(response.VotePenaltyCounter.AbstainCount)
 		successCount := float64(response.VotePenaltyCounter.SuccessCount)
 
-		metrics.votePenaltyCount.WithLabelValues("miss", validatorAddress.String()).Add(missCount)
-		metrics.votePenaltyCount.WithLabelValues("abstain", validatorAddress.String()).Add(abstainCount)
-		metrics.votePenaltyCount.WithLabelValues("success", validatorAddress.String()).Add(successCount)
+		metrics.votePenaltyCount.WithLabelValues("miss", operatorAddress).Add(missCount)
+		metrics.votePenaltyCount.WithLabelValues("abstain", operatorAddress).Add(abstainCount)
+		metrics.votePenaltyCount.WithLabelValues("success", operatorAddress).Add(successCount)
 	}()
 }
 
@@ -85,7 +125,7 @@ func OracleMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Ser
 		Logger()
 
 	address := r.URL.Query().Get("address")
-	myAddress, err := sdk.ValAddressFromBech32(address)
+	myAddress, err := s.Config.ValAddressFromBech32(address)
 	if err != nil {
 		sublogger.Error().
 			Str("address", address).
@@ -97,12 +137,17 @@ func OracleMetricHandler(w http.ResponseWriter, r *http.Request, s *exporter.Ser
 	registry := prometheus.NewRegistry()
 	seiMetrics := NewSeiMetrics(registry, s.Config)
 
+	ctx, cancel := s.ScrapeContext(r)
+	defer cancel()
+	ctx, run := s.StartCollector(ctx, "sei")
+
 	var wg sync.WaitGroup
-	getSeiMetrics(&wg, &sublogger, seiMetrics, s, s.Config, myAddress)
+	getSeiMetrics(ctx, &wg, &sublogger, seiMetrics, s, s.Config, myAddress)
 
 	wg.Wait()
+	s.FinishCollector(run)
 
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h := promhttp.HandlerFor(s.Gatherer(registry), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 	sublogger.Info().
 		Str("method", "GET").
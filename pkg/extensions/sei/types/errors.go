@@ -0,0 +1,36 @@
+package types
+
+import (
+	"fmt"
+
+	"cosmossdk.io/errors"
+)
+
+const TruncatedSize = 20
+
+// Codespace differs from the sei module name, which is taken by the kujira oracle errors
+// once every extension is compiled into the same binary.
+const Codespace = "sei" + ModuleName
+
+// Oracle Errors
+var (
+	ErrInvalidExchangeRate   = errors.Register(Codespace, 2, "invalid exchange rate")
+	ErrNoVote                = errors.Register(Codespace, 4, "no vote")
+	ErrNoVotingPermission    = errors.Register(Codespace, 5, "unauthorized voter")
+	ErrInvalidHash           = errors.Register(Codespace, 6, "invalid hash")
+	ErrInvalidHashLength     = errors.Register(Codespace, 7, fmt.Sprintf("invalid hash length; should equal %d", TruncatedSize))
+	ErrVerificationFailed    = errors.Register(Codespace, 8, "hash verification failed")
+	ErrNoAggregateVote       = errors.Register(Codespace, 12, "no aggregate vote")
+	ErrNoVoteTarget          = errors.Register(Codespace, 13, "no vote target")
+	ErrUnknownDenom          = errors.Register(Codespace, 14, "unknown denom")
+	ErrNoLatestPriceSnapshot = errors.Register(Codespace, 15, "no latest snapshot")
+	ErrInvalidTwapLookback   = errors.Register(Codespace, 16, "Twap lookback seconds is greater than max lookback duration or less than or equal to 0")
+	ErrNoTwapData            = errors.Register(Codespace, 17, "No data for the twap calculation")
+	ErrParsingOracleQuery    = errors.Register(Codespace, 18, "Error parsing SeiOracleQuery")
+	ErrGettingExchangeRates  = errors.Register(Codespace, 19, "Error while getting Exchange Rates")
+	ErrEncodingExchangeRates = errors.Register(Codespace, 20, "Error encoding exchange rates as JSON")
+	ErrGettingOracleTwaps    = errors.Register(Codespace, 21, "Error while getting Oracle Twaps in wasmd")
+	ErrEncodingOracleTwaps   = errors.Register(Codespace, 22, "Error encoding oracle twaps as JSON")
+	ErrUnknownSeiOracleQuery = errors.Register(Codespace, 23, "Error unknown sei oracle query")
+	ErrAggregateVoteExist    = errors.Register(Codespace, 24, "aggregate vote still present in current voting window")
+)
@@ -9,7 +9,7 @@ import (
 
 	paramstypes "github.com/cosmos/cosmos-sdk/x/params/types"
 
-	"github.com/pfc-developer/cosmos-exporter/cmd/sei-cosmos-exporter/utils"
+	"github.com/pfc-developer/cosmos-exporter/pkg/extensions/sei/utils"
 )
 
 // Parameter keys